@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/filesd"
+)
+
+// runFileSD writes file_sd target files on every cache interval
+func runFileSD(a *app, args []string) {
+	flags := flag.NewFlagSet("file-sd", flag.ExitOnError)
+	directory := flags.String("dir", a.cfg.FileSD.Directory, "directory to write target files to")
+	format := flags.String("format", a.cfg.FileSD.Format, "target file format: json or yaml")
+	once := flags.Bool("once", false, "write the target files once and exit")
+	flags.Parse(args)
+
+	fileSDConfig := a.cfg.FileSD
+	fileSDConfig.Directory = *directory
+	fileSDConfig.Format = *format
+
+	writer, err := filesd.NewWriter(a.inv, a.labelBuilder, fileSDConfig, a.cfg.TargetProfiles)
+	if err != nil {
+		log.Fatalf("Invalid file_sd configuration: %v", err)
+	}
+
+	if *once {
+		if err := writer.WriteOnce(); err != nil {
+			log.Fatalf("Failed to write file_sd targets: %v", err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Writing file_sd targets to %s every %v", fileSDConfig.Directory, a.cfg.CacheDuration)
+	writer.Run(ctx, a.cfg.CacheDuration)
+}
@@ -2,17 +2,34 @@ package main
 
 import (
 	"log"
-	"net/http"
+	"os"
+	"strings"
 
 	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/cache"
 	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
 	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
-	httpHandler "github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/http"
-	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/handlers"
-	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/middleware"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/enrich"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/webhook"
 )
 
+// app holds the components shared by every command
+type app struct {
+	cfg          *config.Config
+	inv          *inventory.Inventory
+	pipeline     *enrich.Pipeline
+	labelBuilder *labels.Builder
+}
+
 func main() {
+	// The first argument selects the command, defaulting to serve
+	command := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -27,26 +44,98 @@ func main() {
 	// Log configuration (excluding sensitive information)
 	log.Printf("Configuration loaded successfully")
 	log.Printf("Cache duration set to %v", cfg.CacheDuration)
+	log.Printf("Max staleness set to %v", cfg.MaxStaleness)
+
+	a := newApp(cfg)
+
+	switch command {
+	case "serve":
+		runServe(a, args)
+	case "file-sd":
+		runFileSD(a, args)
+	case "manifests":
+		runManifests(a, args)
+	case "otel":
+		runOTel(a, args)
+	default:
+		log.Fatalf("Unknown command %q, must be one of: serve, file-sd, manifests, otel", command)
+	}
+}
 
+// newApp builds the client, enrichment pipeline and inventory from the configuration
+func newApp(cfg *config.Config) *app {
 	// Initialize Confluent API client
 	client := confluent.NewClient(cfg.ConfluentAPIKey, cfg.ConfluentAPISecret)
 
-	// Initialize cache
-	cacheInstance := cache.New()
+	// Compile custom label templates
+	labelTemplates, err := labels.NewTemplates(cfg.LabelTemplates)
+	if err != nil {
+		log.Fatalf("Failed to compile label templates: %v", err)
+	}
+	if len(cfg.LabelTemplates) > 0 {
+		log.Printf("Loaded %d custom label templates", len(cfg.LabelTemplates))
+	}
 
-	// Create router
-	mux := http.NewServeMux()
+	// Configure label normalisation
+	normalizer, err := labels.NewNormalizer(cfg.Normalization.Casing, cfg.Normalization.MaxValueLength, cfg.Normalization.SlugLabels)
+	if err != nil {
+		log.Fatalf("Invalid normalization configuration: %v", err)
+	}
+	labelBuilder := labels.NewBuilder(labelTemplates, normalizer)
+
+	// Build the enrichment pipeline
+	var stages []enrich.Stage
+	if len(cfg.NameRules) > 0 {
+		nameParser, err := enrich.NewNameParser(cfg.NameRules)
+		if err != nil {
+			log.Fatalf("Failed to compile name rules: %v", err)
+		}
+		stages = append(stages, nameParser)
+		log.Printf("Loaded %d name rules", len(cfg.NameRules))
+	}
+	if cfg.Ownership.File != "" {
+		ownership, err := enrich.NewOwnership(cfg.Ownership)
+		if err != nil {
+			log.Fatalf("Failed to load ownership file: %v", err)
+		}
+		stages = append(stages, ownership)
+		log.Printf("Loaded ownership file %s", cfg.Ownership.File)
+	}
+	pipeline := enrich.NewPipeline(stages...)
 
-	// Auth middleware
-	authMiddleware := middleware.AuthMiddleware(cfg.ConfluentAPIKey)
+	// Initialize cache
+	cacheInstance := cache.New[string, []confluent.Resource]()
 
-	// Register handlers
-	mux.Handle("/health", httpHandler.HealthHandler())
-	mux.Handle("/discovery", authMiddleware(handlers.DiscoveryHandler(client, cacheInstance, cfg.CacheDuration)))
+	// Initialize the shared resource inventory
+	options := inventory.Options{
+		CacheDuration:  cfg.CacheDuration,
+		TypeTTLs:       cfg.Cache.TTLs,
+		JitterPercent:  cfg.Cache.JitterPercent,
+		MaxStaleness:   cfg.MaxStaleness,
+		MaxDropPercent: cfg.ShrinkGuard.MaxDropPercent,
+		SnapshotPath:   cfg.SnapshotPath,
+		ChangeHistory:  cfg.Changes.History,
+	}
+	if len(cfg.Webhooks) > 0 {
+		notifier, err := webhook.NewNotifier(cfg.Webhooks)
+		if err != nil {
+			log.Fatalf("Invalid webhook configuration: %v", err)
+		}
+		options.OnChange = notifier.Notify
+		log.Printf("Loaded %d webhooks", len(cfg.Webhooks))
+	}
+	if err := options.Validate(); err != nil {
+		log.Fatalf("Invalid inventory configuration: %v", err)
+	}
+	for resourceType, ttl := range cfg.Cache.TTLs {
+		log.Printf("Cache duration for %s set to %v", resourceType, ttl)
+	}
+	inv := inventory.New(client, cacheInstance, pipeline, options)
 
-	// Start the server
-	log.Printf("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", mux); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	return &app{
+		cfg:          cfg,
+		inv:          inv,
+		pipeline:     pipeline,
+		labelBuilder: labelBuilder,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/manifests"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// runManifests renders Prometheus Operator manifests for the current inventory
+func runManifests(a *app, args []string) {
+	flags := flag.NewFlagSet("manifests", flag.ExitOnError)
+	targetsFlag := flags.String("targets", manifests.DefaultTarget, "comma-separated scrape targets")
+	profile := flags.String("profile", "", "target profile from the configuration file, overrides -targets")
+	prefixFlag := flags.String("prefix", "", "label prefix")
+	includeProbes := flags.Bool("probes", false, "also render Probe manifests for the blackbox exporter")
+	output := flags.String("o", "-", "output file, - for stdout")
+	flags.Parse(args)
+
+	assignment := targets.Assignment{Fallback: strings.Split(*targetsFlag, ",")}
+	if *profile != "" {
+		targetProfile, ok := a.cfg.TargetProfiles[*profile]
+		if !ok {
+			log.Fatalf("Unknown target profile %q", *profile)
+		}
+		assignment = targets.FromProfile(targetProfile)
+	}
+
+	prefix, err := targets.ParsePrefix(*prefixFlag)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	resources, err := a.inv.Resources()
+	if err != nil {
+		log.Fatalf("Failed to fetch resources: %v", err)
+	}
+
+	generator := manifests.NewGenerator(a.cfg.Manifests, a.labelBuilder, a.cfg.ProbeModules)
+
+	var objects []interface{}
+	for _, scrapeConfig := range generator.ScrapeConfigs(resources, assignment, prefix) {
+		objects = append(objects, scrapeConfig)
+	}
+
+	if *includeProbes {
+		probes, err := generator.Probes(resources, prefix)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		for _, probe := range probes {
+			objects = append(objects, probe)
+		}
+	}
+
+	content, err := manifests.Render(objects...)
+	if err != nil {
+		log.Fatalf("Failed to render manifests: %v", err)
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+
+	if err := os.WriteFile(*output, content, 0o644); err != nil {
+		log.Fatalf("Failed to write manifests: %v", err)
+	}
+	log.Printf("Wrote %d manifests to %s", len(objects), *output)
+}
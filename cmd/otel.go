@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/otel"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// runOTel renders an OpenTelemetry Collector prometheus receiver configuration
+func runOTel(a *app, args []string) {
+	flags := flag.NewFlagSet("otel", flag.ExitOnError)
+	mode := flags.String("mode", otel.ModeStatic, "static targets or http_sd pointing back at this service")
+	targetsFlag := flags.String("targets", otel.DefaultTarget, "comma-separated scrape targets")
+	profile := flags.String("profile", "", "target profile from the configuration file, overrides -targets")
+	prefixFlag := flags.String("prefix", "", "label prefix")
+	semanticConventions := flags.Bool("semconv", false, "map cloud labels to OpenTelemetry semantic convention attributes")
+	output := flags.String("o", "-", "output file, - for stdout")
+	flags.Parse(args)
+
+	assignment := targets.Assignment{Fallback: strings.Split(*targetsFlag, ",")}
+	if *profile != "" {
+		targetProfile, ok := a.cfg.TargetProfiles[*profile]
+		if !ok {
+			log.Fatalf("Unknown target profile %q", *profile)
+		}
+		assignment = targets.FromProfile(targetProfile)
+	}
+
+	prefix, err := targets.ParsePrefix(*prefixFlag)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	generator := otel.NewGenerator(a.cfg.OTel, a.labelBuilder)
+
+	var cfg otel.Config
+	switch *mode {
+	case otel.ModeStatic:
+		resources, err := a.inv.Resources()
+		if err != nil {
+			log.Fatalf("Failed to fetch resources: %v", err)
+		}
+		cfg = generator.Static(resources, assignment, prefix, *semanticConventions)
+	case otel.ModeHTTPSD:
+		// The service resolves the profile itself
+		query := url.Values{}
+		if *profile != "" {
+			query.Set("profile", *profile)
+		} else {
+			query.Set("targets", *targetsFlag)
+		}
+		if *prefixFlag != "" {
+			query.Set("prefix", *prefixFlag)
+		}
+
+		cfg, err = generator.HTTPSD(query, *semanticConventions)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	default:
+		log.Fatalf("Invalid mode %q, must be %q or %q", *mode, otel.ModeStatic, otel.ModeHTTPSD)
+	}
+
+	content, err := otel.Render(cfg)
+	if err != nil {
+		log.Fatalf("Failed to render OpenTelemetry configuration: %v", err)
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+
+	if err := os.WriteFile(*output, content, 0o644); err != nil {
+		log.Fatalf("Failed to write OpenTelemetry configuration: %v", err)
+	}
+	log.Printf("Wrote OpenTelemetry configuration to %s", *output)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/export"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/handlers"
+	httpHandler "github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/http"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/manifests"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/middleware"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/otel"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// runServe runs the HTTP service discovery server
+func runServe(a *app, args []string) {
+	if len(args) > 0 {
+		log.Fatalf("Unexpected arguments for serve: %v", args)
+	}
+
+	// Start from the persisted snapshot, if any, instead of a cold cache. The
+	// one-shot commands always fetch from the Confluent API instead.
+	if err := a.inv.LoadSnapshot(); err != nil {
+		log.Printf("Warning: failed to load snapshot: %v", err)
+	}
+
+	// Renew the cached resources in the background before they expire
+	go a.inv.Run(context.Background(), a.cfg.RefreshInterval)
+	log.Printf("Refreshing resources every %v", a.cfg.RefreshInterval)
+
+	// Create router
+	mux := http.NewServeMux()
+
+	// Auth middleware
+	authMiddleware := middleware.AuthMiddleware(a.cfg.ConfluentAPIKey)
+
+	// Proxy for the Metrics API export endpoint
+	exportProxy, err := export.NewProxy(a.cfg.Export, a.cfg.ConfluentAPIKey, a.cfg.ConfluentAPISecret, a.inv, a.labelBuilder)
+	if err != nil {
+		log.Fatalf("Invalid export configuration: %v", err)
+	}
+
+	// Register handlers
+	mux.Handle("/health", httpHandler.HealthHandler())
+	mux.Handle("/health/inventory", handlers.InventoryStatusHandler(a.inv))
+	mux.Handle("/discovery", authMiddleware(handlers.DiscoveryHandler(a.inv, a.labelBuilder, a.cfg.TargetProfiles, targets.BatchLimits(a.cfg.Export.BatchLimits))))
+	mux.Handle("/discovery/probes", authMiddleware(handlers.ProbesHandler(a.inv, a.labelBuilder, a.cfg.ProbeModules)))
+	mux.Handle("/manifests", authMiddleware(handlers.ManifestsHandler(a.inv, manifests.NewGenerator(a.cfg.Manifests, a.labelBuilder, a.cfg.ProbeModules), a.cfg.TargetProfiles)))
+	mux.Handle("/otel", authMiddleware(handlers.OTelHandler(a.inv, otel.NewGenerator(a.cfg.OTel, a.labelBuilder), a.cfg.TargetProfiles)))
+	mux.Handle("/metrics/inventory", authMiddleware(handlers.InventoryMetricsHandler(a.inv, a.labelBuilder)))
+	mux.Handle("/export", authMiddleware(handlers.ExportHandler(exportProxy)))
+	mux.Handle("/changes", authMiddleware(handlers.ChangesHandler(a.inv)))
+	mux.Handle("/admin/accept-shrink", authMiddleware(handlers.AcceptShrinkHandler(a.inv)))
+	mux.Handle("/debug/enrichment", authMiddleware(handlers.EnrichmentDebugHandler(a.pipeline)))
+
+	// Start the server
+	log.Printf("Starting server on :8080")
+	if err := http.ListenAndServe(":8080", mux); err != nil {
+		log.Fatalf("Server failed to start: %v", err)
+	}
+}
@@ -5,74 +5,115 @@ import (
 	"time"
 )
 
+const (
+	cleanupInterval = 5 * time.Minute
+)
+
+// Clock returns the current time, replaceable for deterministic tests
+type Clock func() time.Time
+
 // Item represents a cached item with expiration
-type Item struct {
-	Value      interface{}
+type Item[V any] struct {
+	Value      V
+	Created    int64
 	Expiration int64
 }
 
 // Cache is a simple in-memory cache with expiration
-type Cache struct {
-	items map[string]Item
-	mu    sync.RWMutex
+type Cache[K comparable, V any] struct {
+	items    map[K]Item[V]
+	mu       sync.RWMutex
+	now      Clock
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a new cache using the system clock
+func New[K comparable, V any]() *Cache[K, V] {
+	return NewWithClock[K, V](time.Now)
 }
 
-// New creates a new cache
-func New() *Cache {
-	cache := &Cache{
-		items: make(map[string]Item),
+// NewWithClock creates a new cache that reads the time from the clock
+func NewWithClock[K comparable, V any](clock Clock) *Cache[K, V] {
+	cache := &Cache[K, V]{
+		items: make(map[K]Item[V]),
+		now:   clock,
+		stop:  make(chan struct{}),
 	}
-	
+
 	// Start cleanup routine
 	go cache.startCleanupTimer()
-	
+
 	return cache
 }
 
 // Set adds an item to the cache with a specified expiration
-func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
+func (c *Cache[K, V]) Set(key K, value V, duration time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	expiration := time.Now().Add(duration).UnixNano()
-	c.items[key] = Item{
+	now := c.now()
+	c.items[key] = Item[V]{
 		Value:      value,
-		Expiration: expiration,
+		Created:    now.UnixNano(),
+		Expiration: now.Add(duration).UnixNano(),
 	}
 }
 
 // Get retrieves an item from the cache
-func (c *Cache) Get(key string) (interface{}, bool) {
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	value, _, found := c.GetWithAge(key)
+	return value, found
+}
+
+// GetWithAge retrieves an item from the cache along with the time since it was set
+func (c *Cache[K, V]) GetWithAge(key K) (V, time.Duration, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	var zero V
+
 	item, found := c.items[key]
 	if !found {
-		return nil, false
+		return zero, 0, false
 	}
 
 	// Check if the item has expired
-	if time.Now().UnixNano() > item.Expiration {
-		return nil, false
+	now := c.now().UnixNano()
+	if now > item.Expiration {
+		return zero, 0, false
 	}
 
-	return item.Value, true
+	return item.Value, time.Duration(now - item.Created), true
+}
+
+// Now returns the current time of the cache clock
+func (c *Cache[K, V]) Now() time.Time {
+	return c.now()
 }
 
 // Delete removes an item from the cache
-func (c *Cache) Delete(key string) {
+func (c *Cache[K, V]) Delete(key K) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	delete(c.items, key)
 }
 
+// Stop ends the cleanup routine. The cache stays usable, but expired items
+// are only dropped when they are overwritten or deleted.
+func (c *Cache[K, V]) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
 // cleanup removes expired items from the cache
-func (c *Cache) cleanup() {
+func (c *Cache[K, V]) cleanup() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	now := time.Now().UnixNano()
+	now := c.now().UnixNano()
 	for k, v := range c.items {
 		if now > v.Expiration {
 			delete(c.items, k)
@@ -81,11 +122,16 @@ func (c *Cache) cleanup() {
 }
 
 // startCleanupTimer starts a timer to periodically clean up expired items
-func (c *Cache) startCleanupTimer() {
-	ticker := time.NewTicker(5 * time.Minute)
+func (c *Cache[K, V]) startCleanupTimer() {
+	ticker := time.NewTicker(cleanupInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.cleanup()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.cleanup()
+		}
 	}
-}
\ No newline at end of file
+}
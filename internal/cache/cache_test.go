@@ -1,12 +1,14 @@
 package cache
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestCacheSetGet(t *testing.T) {
-	cache := New()
+	cache := New[string, string]()
+	defer cache.Stop()
 
 	// Set a value in the cache
 	cache.Set("key1", "value1", 1*time.Minute)
@@ -30,7 +32,8 @@ func TestCacheSetGet(t *testing.T) {
 }
 
 func TestCacheExpiration(t *testing.T) {
-	cache := New()
+	cache := New[string, string]()
+	defer cache.Stop()
 
 	// Set a value with a very short expiration
 	cache.Set("key1", "value1", 1*time.Millisecond)
@@ -46,7 +49,8 @@ func TestCacheExpiration(t *testing.T) {
 }
 
 func TestCacheDelete(t *testing.T) {
-	cache := New()
+	cache := New[string, string]()
+	defer cache.Stop()
 
 	// Set a value
 	cache.Set("key1", "value1", 1*time.Minute)
@@ -62,4 +66,68 @@ func TestCacheDelete(t *testing.T) {
 
 	// Delete a non-existent key should not cause issues
 	cache.Delete("non-existent")
-}
\ No newline at end of file
+}
+
+// fakeClock is a manually advanced clock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+func TestCacheGetWithAge(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	cache := NewWithClock[string, []int](clock.Now)
+	defer cache.Stop()
+
+	cache.Set("key1", []int{1, 2}, time.Minute)
+	clock.Advance(30 * time.Second)
+
+	value, age, found := cache.GetWithAge("key1")
+	if !found {
+		t.Fatal("Expected to find key1 in cache, but it was not found")
+	}
+	if len(value) != 2 {
+		t.Errorf("Expected value [1 2], got %v", value)
+	}
+	if age != 30*time.Second {
+		t.Errorf("Expected age 30s, got %v", age)
+	}
+
+	// Expiry is exact with the injected clock
+	clock.Advance(30 * time.Second)
+	if _, found := cache.Get("key1"); !found {
+		t.Error("Expected key1 to be found at its expiration time")
+	}
+
+	clock.Advance(time.Nanosecond)
+	if value, _, found := cache.GetWithAge("key1"); found || value != nil {
+		t.Errorf("Expected key1 to be expired, got %v", value)
+	}
+}
+
+func TestCacheStop(t *testing.T) {
+	cache := New[int, string]()
+
+	// Stopping twice must not panic
+	cache.Stop()
+	cache.Stop()
+
+	cache.Set(1, "value1", time.Minute)
+	if value, found := cache.Get(1); !found || value != "value1" {
+		t.Errorf("Expected the cache to stay usable after Stop, got %q", value)
+	}
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds application configuration
@@ -12,6 +15,187 @@ type Config struct {
 	ConfluentAPIKey    string
 	ConfluentAPISecret string
 	CacheDuration      time.Duration
+	RefreshInterval    time.Duration
+	MaxStaleness       time.Duration
+	SnapshotPath       string
+
+	// Settings below are read from the optional YAML file named by CONFIG_FILE
+	ConfigFile     string
+	LabelTemplates map[string]string
+	NameRules      []NameRule
+	Ownership      OwnershipConfig
+	Normalization  NormalizationConfig
+	TargetProfiles map[string]TargetProfile
+	ProbeModules   map[string]string
+	FileSD         FileSDConfig
+	Manifests      ManifestsConfig
+	Export         ExportConfig
+	OTel           OTelConfig
+	ShrinkGuard    ShrinkGuardConfig
+	Cache          CacheConfig
+	Changes        ChangesConfig
+	Webhooks       []WebhookConfig
+}
+
+// NameRule describes a regular expression applied to a display name label.
+// Every named capture group becomes a label on matching resources.
+type NameRule struct {
+	ResourceType string `yaml:"resource_type"`
+	Source       string `yaml:"source"`
+	Pattern      string `yaml:"pattern"`
+}
+
+// OwnershipConfig points at an external inventory of resource owners
+type OwnershipConfig struct {
+	// File is a YAML or CSV file mapping resource IDs or name globs to labels
+	File string `yaml:"file"`
+	// Precedence decides which side wins on conflicting labels: "confluent" (default) or "file"
+	Precedence string `yaml:"precedence"`
+}
+
+// NormalizationConfig controls how label values are normalised
+type NormalizationConfig struct {
+	// Casing is applied to label values: "preserve" (default), "lower" or "upper"
+	Casing string `yaml:"casing"`
+	// MaxValueLength truncates longer label values, 0 disables truncation
+	MaxValueLength int `yaml:"max_value_length"`
+	// SlugLabels get an additional "<name>_slug" label with a lowercase slug of the value
+	SlugLabels []string `yaml:"slug_labels"`
+}
+
+// TargetProfile is a named set of scrape targets selected with the profile query parameter
+type TargetProfile struct {
+	// Targets apply to every resource type without its own entry
+	Targets []string `yaml:"targets"`
+	// ResourceTypes override the targets for individual resource types
+	ResourceTypes map[string][]string `yaml:"resource_types"`
+}
+
+// FileSDConfig configures the file-sd run mode, which writes target files to disk
+type FileSDConfig struct {
+	// Directory receives one file per job
+	Directory string `yaml:"directory"`
+	// Format of the written files: "json" (default) or "yaml"
+	Format string `yaml:"format"`
+	// Targets, Profile and Prefix are defaults for every job
+	Targets []string `yaml:"targets"`
+	Profile string   `yaml:"profile"`
+	Prefix  string   `yaml:"prefix"`
+	// Jobs to write. Without jobs, one file is written per resource type.
+	Jobs []FileSDJob `yaml:"jobs"`
+}
+
+// FileSDJob describes a single target file
+type FileSDJob struct {
+	Name string `yaml:"name"`
+	// ResourceTypes limits the job to these resource types, empty means all
+	ResourceTypes []string `yaml:"resource_types"`
+	Targets       []string `yaml:"targets"`
+	Profile       string   `yaml:"profile"`
+	Prefix        string   `yaml:"prefix"`
+}
+
+// ManifestsConfig controls the Prometheus Operator manifests rendered by the manifests command and endpoint
+type ManifestsConfig struct {
+	Namespace string `yaml:"namespace"`
+	// Labels are set on every generated object, e.g. to match a Prometheus scrapeConfigSelector
+	Labels map[string]string `yaml:"labels"`
+	// NamePrefix starts every object name (default "confluent")
+	NamePrefix string `yaml:"name_prefix"`
+	// SecretName, UsernameKey and PasswordKey reference the Confluent API credentials
+	SecretName  string `yaml:"secret_name"`
+	UsernameKey string `yaml:"username_key"`
+	PasswordKey string `yaml:"password_key"`
+	// MetricsPath defaults to the Metrics API export path
+	MetricsPath    string `yaml:"metrics_path"`
+	ScrapeInterval string `yaml:"scrape_interval"`
+	// ProberURL is the blackbox exporter address used by Probe manifests
+	ProberURL string `yaml:"prober_url"`
+}
+
+// ExportConfig controls the proxy in front of the Confluent Cloud Metrics API export endpoint
+type ExportConfig struct {
+	// URL of the export endpoint, defaults to the Confluent Cloud Metrics API
+	URL string `yaml:"url"`
+	// Timeout of upstream requests, e.g. "30s"
+	Timeout string `yaml:"timeout"`
+	// BatchLimits caps the resource IDs per export request by resource type
+	BatchLimits map[string]int `yaml:"batch_limits"`
+}
+
+// OTelConfig controls the OpenTelemetry Collector receiver configuration rendered by the otel command and endpoint
+type OTelConfig struct {
+	// JobName names the scrape jobs (default "confluent-cloud")
+	JobName string `yaml:"job_name"`
+	// ServiceURL is the address of this service used by http_sd mode
+	ServiceURL string `yaml:"service_url"`
+	// Username and Password are the Metrics API basic auth credentials, defaulting to collector environment variables
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// MetricsPath defaults to the Metrics API export path
+	MetricsPath    string `yaml:"metrics_path"`
+	ScrapeInterval string `yaml:"scrape_interval"`
+	// RefreshInterval of the http_sd configuration (default "30m")
+	RefreshInterval string `yaml:"refresh_interval"`
+}
+
+// ShrinkGuardConfig protects the served resources against refreshes that suddenly lose most of them
+type ShrinkGuardConfig struct {
+	// MaxDropPercent rejects resource types losing more than this share of their
+	// resources, or all of them. 0 disables the guard.
+	MaxDropPercent float64 `yaml:"max_drop_percent"`
+}
+
+// CacheConfig tunes how long each resource type is cached
+type CacheConfig struct {
+	// TTLs override CACHE_DURATION by resource type, e.g. connector: 5m.
+	// Types with their own TTL are refreshed on their own schedule.
+	TTLs map[string]time.Duration `yaml:"ttls"`
+	// JitterPercent adds up to this share of the TTL to every expiry
+	JitterPercent float64 `yaml:"jitter_percent"`
+}
+
+// ChangesConfig controls the history of changes between refreshes served by /changes
+type ChangesConfig struct {
+	// History is the number of refreshes with changes kept (default 100)
+	History int `yaml:"history"`
+}
+
+// WebhookConfig describes an outgoing webhook notified when resources are added or removed
+type WebhookConfig struct {
+	Name string `yaml:"name"`
+	// URL receives the notifications, environment variables like ${SLACK_WEBHOOK_URL} are expanded
+	URL string `yaml:"url"`
+	// Format of the payload: "json" (default) or "slack"
+	Format string `yaml:"format"`
+	// Secret signs payloads with HMAC-SHA256 when set, environment variables are expanded
+	Secret string `yaml:"secret"`
+	// ResourceTypes limits notifications to these resource types, empty means all
+	ResourceTypes []string `yaml:"resource_types"`
+	// Timeout of a single delivery attempt (default "10s")
+	Timeout string `yaml:"timeout"`
+	// MaxRetries after a failed delivery (default 3)
+	MaxRetries *int `yaml:"max_retries"`
+	// Backoff before the first retry, doubled for every further retry (default "1s")
+	Backoff string `yaml:"backoff"`
+}
+
+// fileConfig mirrors the structure of the optional YAML configuration file
+type fileConfig struct {
+	LabelTemplates map[string]string        `yaml:"label_templates"`
+	NameRules      []NameRule               `yaml:"name_rules"`
+	Ownership      OwnershipConfig          `yaml:"ownership"`
+	Normalization  NormalizationConfig      `yaml:"normalization"`
+	TargetProfiles map[string]TargetProfile `yaml:"target_profiles"`
+	ProbeModules   map[string]string        `yaml:"probe_modules"`
+	FileSD         FileSDConfig             `yaml:"file_sd"`
+	Manifests      ManifestsConfig          `yaml:"manifests"`
+	Export         ExportConfig             `yaml:"export"`
+	OTel           OTelConfig               `yaml:"otel"`
+	ShrinkGuard    ShrinkGuardConfig        `yaml:"shrink_guard"`
+	Cache          CacheConfig              `yaml:"cache"`
+	Changes        ChangesConfig            `yaml:"changes"`
+	Webhooks       []WebhookConfig          `yaml:"webhooks"`
 }
 
 // Load loads configuration from environment variables
@@ -31,9 +215,79 @@ func Load() (*Config, error) {
 		}
 	}
 
-	return &Config{
+	// Refresh before the cache expires, by default halfway through
+	refreshInterval := cacheDuration / 2
+	if refreshInterval <= 0 {
+		refreshInterval = time.Minute
+	}
+
+	refreshIntervalStr := os.Getenv("REFRESH_INTERVAL")
+	if refreshIntervalStr != "" {
+		intervalMinutes, err := strconv.Atoi(refreshIntervalStr)
+		if err != nil || intervalMinutes <= 0 {
+			log.Printf("Invalid REFRESH_INTERVAL value: %s, using default of %v", refreshIntervalStr, refreshInterval)
+		} else {
+			refreshInterval = time.Duration(intervalMinutes) * time.Minute
+		}
+	}
+
+	// Serve the last good snapshot for up to a day while the API is failing
+	maxStaleness := 24 * time.Hour
+
+	maxStalenessStr := os.Getenv("MAX_STALENESS")
+	if maxStalenessStr != "" {
+		stalenessMinutes, err := strconv.Atoi(maxStalenessStr)
+		if err != nil || stalenessMinutes < 0 {
+			log.Printf("Invalid MAX_STALENESS value: %s, using default of %v", maxStalenessStr, maxStaleness)
+		} else {
+			maxStaleness = time.Duration(stalenessMinutes) * time.Minute
+		}
+	}
+
+	cfg := &Config{
 		ConfluentAPIKey:    apiKey,
 		ConfluentAPISecret: apiSecret,
 		CacheDuration:      cacheDuration,
-	}, nil
-}
\ No newline at end of file
+		RefreshInterval:    refreshInterval,
+		MaxStaleness:       maxStaleness,
+		SnapshotPath:       os.Getenv("SNAPSHOT_PATH"),
+		ConfigFile:         os.Getenv("CONFIG_FILE"),
+	}
+
+	if cfg.ConfigFile != "" {
+		if err := cfg.loadFile(cfg.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadFile reads the YAML configuration file and applies its settings
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var fc fileConfig
+	if err := yaml.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	c.LabelTemplates = fc.LabelTemplates
+	c.NameRules = fc.NameRules
+	c.Ownership = fc.Ownership
+	c.Normalization = fc.Normalization
+	c.TargetProfiles = fc.TargetProfiles
+	c.ProbeModules = fc.ProbeModules
+	c.FileSD = fc.FileSD
+	c.Manifests = fc.Manifests
+	c.Export = fc.Export
+	c.OTel = fc.OTel
+	c.ShrinkGuard = fc.ShrinkGuard
+	c.Cache = fc.Cache
+	c.Changes = fc.Changes
+	c.Webhooks = fc.Webhooks
+	return nil
+}
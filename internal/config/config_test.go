@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -72,4 +73,43 @@ func TestLoadDefaultCacheDuration(t *testing.T) {
 	// Clean up
 	os.Unsetenv("CONFLUENT_API_KEY")
 	os.Unsetenv("CONFLUENT_API_SECRET")
-}
\ No newline at end of file
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `label_templates:
+  service: "{{ .Labels.environment_name }}-{{ .Labels.cluster_name }}"
+cache:
+  ttls:
+    connector: 5m
+  jitter_percent: 10
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	os.Setenv("CONFIG_FILE", path)
+	defer os.Unsetenv("CONFIG_FILE")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	expected := "{{ .Labels.environment_name }}-{{ .Labels.cluster_name }}"
+	if cfg.LabelTemplates["service"] != expected {
+		t.Errorf("Expected service template '%s', got '%s'", expected, cfg.LabelTemplates["service"])
+	}
+	if cfg.Cache.TTLs["connector"] != 5*time.Minute {
+		t.Errorf("Expected connector TTL 5m, got %v", cfg.Cache.TTLs["connector"])
+	}
+	if cfg.Cache.JitterPercent != 10 {
+		t.Errorf("Expected jitter of 10%%, got %v", cfg.Cache.JitterPercent)
+	}
+
+	// A missing config file is an error
+	os.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a missing config file, got nil")
+	}
+}
@@ -1,6 +1,7 @@
 package confluent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -27,6 +28,7 @@ type Client struct {
 	httpClient  *http.Client
 	apiKey      string
 	apiSecret   string
+	ctx         context.Context
 }
 
 // Environment represents a Confluent Cloud environment
@@ -51,14 +53,33 @@ type Resource struct {
 	ID           string            `json:"id"`
 	ResourceType string            `json:"resource_type"`
 	Labels       map[string]string `json:"labels"`
+	Endpoints    map[string]string `json:"endpoints,omitempty"` // Network endpoints keyed by kind, e.g. "bootstrap" or "http"
+	Environment  string            `json:"environment_id,omitempty"`
+}
+
+// Segment identifies the resources of one type in one environment, the unit
+// in which resources are fetched and can fail
+type Segment struct {
+	EnvironmentID string `json:"environment_id"`
+	ResourceType  string `json:"resource_type"`
+	// ClusterID is set on connector segments, which are listed per Kafka cluster.
+	// Connector segments without it cover the whole environment.
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+// FetchResult holds the fetched resources and the segments that failed to load
+type FetchResult struct {
+	Resources []Resource
+	Failed    map[Segment]error
 }
 
 // KafkaClusterSpec represents the specification of a Kafka cluster
 type KafkaClusterSpec struct {
-	DisplayName  string `json:"display_name"`
-	Availability string `json:"availability"`
-	Cloud        string `json:"cloud"`
-	Region       string `json:"region"`
+	DisplayName            string `json:"display_name"`
+	Availability           string `json:"availability"`
+	Cloud                  string `json:"cloud"`
+	Region                 string `json:"region"`
+	KafkaBootstrapEndpoint string `json:"kafka_bootstrap_endpoint"`
 }
 
 // KafkaCluster represents a Kafka cluster
@@ -83,10 +104,11 @@ type KafkaClustersResponse struct {
 
 // SchemaRegistrySpec represents the specification of a Schema Registry instance
 type SchemaRegistrySpec struct {
-	DisplayName string                 `json:"display_name"`
-	Cloud       string                 `json:"cloud"`
-	Region      map[string]interface{} `json:"region"` // Region can be a complex object, not just string
-	Package     string                 `json:"package,omitempty"`
+	DisplayName  string                 `json:"display_name"`
+	Cloud        string                 `json:"cloud"`
+	Region       map[string]interface{} `json:"region"` // Region can be a complex object, not just string
+	Package      string                 `json:"package,omitempty"`
+	HTTPEndpoint string                 `json:"http_endpoint"`
 }
 
 // SchemaRegistry represents a Schema Registry instance
@@ -111,9 +133,10 @@ type SchemaRegistryResponse struct {
 
 // KsqlDBSpec represents the specification of a KSQL database
 type KsqlDBSpec struct {
-	DisplayName string `json:"display_name"`
-	Cloud       string `json:"cloud"`
-	Region      string `json:"region"`
+	DisplayName  string `json:"display_name"`
+	Cloud        string `json:"cloud"`
+	Region       string `json:"region"`
+	HTTPEndpoint string `json:"http_endpoint"`
 }
 
 // KsqlDB represents a KSQL database
@@ -181,6 +204,14 @@ func NewClient(apiKey, apiSecret string) *Client {
 	}
 }
 
+// WithContext returns a copy of the client whose requests are bound to the
+// context, so they are cancelled along with it
+func (c *Client) WithContext(ctx context.Context) *Client {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
 // makeRequest performs an HTTP request and returns the response body
 func (c *Client) makeRequest(method, path string, queryParams map[string]string) ([]byte, error) {
 	// Build URL with query parameters
@@ -196,8 +227,13 @@ func (c *Client) makeRequest(method, path string, queryParams map[string]string)
 	}
 	reqURL.RawQuery = query.Encode()
 	
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	
 	// Create request
-	req, err := http.NewRequest(method, reqURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -488,167 +524,3 @@ func (c *Client) GetConnectors(environmentID, clusterID string) ([]Connector, er
 	log.Printf("Found %d connectors for environment %s, cluster %s", len(connectors), environmentID, clusterID)
 	return connectors, nil
 }
-
-// GetAllResources fetches all resources and formats them with consistent metadata
-func (c *Client) GetAllResources() ([]Resource, error) {
-	var resources []Resource
-	
-	// Fetch environments with pagination
-	environments, err := c.GetEnvironments()
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch environments: %w", err)
-	}
-	
-	// Create a map of environment IDs to names for easier lookup
-	envMap := make(map[string]string)
-	for _, env := range environments {
-		envMap[env.ID] = env.Name
-	}
-	
-	// Process each environment separately
-	for _, env := range environments {
-		log.Printf("Processing environment: %s (%s)", env.Name, env.ID)
-		
-		// Fetch Kafka clusters for this environment with pagination
-		kafkaClusters, err := c.GetKafkaClusters(env.ID)
-		if err != nil {
-			log.Printf("Warning: failed to fetch Kafka clusters for environment %s: %v", env.ID, err)
-		} else {
-			for _, cluster := range kafkaClusters {
-				// Map cloud provider from cloud field
-				cloudProvider := cluster.Spec.Cloud
-				if cloudProvider == "" {
-					cloudProvider = "unknown"
-				}
-				
-				resources = append(resources, Resource{
-					ID:           cluster.ID,
-					ResourceType: "kafka",
-					Labels: map[string]string{
-						"cloud_provider":   cloudProvider,
-						"environment_name": env.Name,
-						"cluster_name":     cluster.Spec.DisplayName,
-						"region":           cluster.Spec.Region,
-					},
-				})
-				
-				// Fetch connectors for this Kafka cluster
-				connectors, err := c.GetConnectors(env.ID, cluster.ID)
-				if err != nil {
-					log.Printf("Warning: failed to fetch connectors for environment %s, cluster %s: %v", 
-						env.ID, cluster.ID, err)
-				} else {
-					for _, connector := range connectors {
-						resources = append(resources, Resource{
-							ID:           connector.ID,
-							ResourceType: "connector",
-							Labels: map[string]string{
-								"cloud_provider":   cloudProvider, // Use cluster's provider
-								"environment_name": env.Name,
-								"connector_name":   connector.ID,
-								"cluster_id":       connector.ClusterID,
-								"region":           cluster.Spec.Region,
-							},
-						})
-					}
-				}
-			}
-		}
-		
-		// Fetch Schema Registry instances for this environment with pagination
-		schemaRegistries, err := c.GetSchemaRegistries(env.ID)
-		if err != nil {
-			log.Printf("Warning: failed to fetch Schema Registry instances for environment %s: %v", env.ID, err)
-		} else {
-			for _, sr := range schemaRegistries {
-				// Map cloud provider from cloud field
-				cloudProvider := sr.Spec.Cloud
-				if cloudProvider == "" {
-					cloudProvider = "unknown"
-				}
-				
-				// Extract region information safely
-				var regionStr string
-				if regionVal, ok := sr.Spec.Region["id"]; ok {
-					if regionStr, ok = regionVal.(string); !ok {
-						regionStr = "unknown"
-					}
-				} else {
-					regionStr = "unknown"
-				}
-				
-				// Create labels map
-				labels := map[string]string{
-					"cloud_provider":   cloudProvider,
-					"environment_name": env.Name,
-					"name":             sr.Spec.DisplayName,
-					"region":           regionStr,
-				}
-				
-				// Add package if available
-				if sr.Spec.Package != "" {
-					labels["package"] = sr.Spec.Package
-				}
-				
-				resources = append(resources, Resource{
-					ID:           sr.ID,
-					ResourceType: "schema_registry",
-					Labels:       labels,
-				})
-			}
-		}
-		
-		// Fetch KSQL databases for this environment with pagination
-		ksqlDBs, err := c.GetKsqlDBs(env.ID)
-		if err != nil {
-			log.Printf("Warning: failed to fetch KSQL databases for environment %s: %v", env.ID, err)
-		} else {
-			for _, ksql := range ksqlDBs {
-				// Map cloud provider from cloud field
-				cloudProvider := ksql.Spec.Cloud
-				if cloudProvider == "" {
-					cloudProvider = "unknown"
-				}
-				
-				resources = append(resources, Resource{
-					ID:           ksql.ID,
-					ResourceType: "ksql",
-					Labels: map[string]string{
-						"cloud_provider":   cloudProvider,
-						"environment_name": env.Name,
-						"name":             ksql.Spec.DisplayName,
-						"region":           ksql.Spec.Region,
-					},
-				})
-			}
-		}
-		
-		// Fetch compute pools for this environment with pagination
-		computePools, err := c.GetComputePools(env.ID)
-		if err != nil {
-			log.Printf("Warning: failed to fetch compute pools for environment %s: %v", env.ID, err)
-		} else {
-			for _, pool := range computePools {
-				// Map cloud provider from cloud field
-				cloudProvider := pool.Spec.Cloud
-				if cloudProvider == "" {
-					cloudProvider = "unknown"
-				}
-				
-				resources = append(resources, Resource{
-					ID:           pool.ID,
-					ResourceType: "compute_pool",
-					Labels: map[string]string{
-						"cloud_provider":   cloudProvider,
-						"environment_name": env.Name,
-						"name":             pool.Spec.DisplayName,
-						"region":           pool.Spec.Region,
-					},
-				})
-			}
-		}
-	}
-	
-	log.Printf("Found %d total resources across %d environments", len(resources), len(environments))
-	return resources, nil
-}
\ No newline at end of file
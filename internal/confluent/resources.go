@@ -0,0 +1,265 @@
+package confluent
+
+import (
+	"fmt"
+	"log"
+	"slices"
+)
+
+// ResourceTypes lists every resource type in the order they are fetched
+var ResourceTypes = []string{"kafka", "connector", "schema_registry", "ksql", "compute_pool"}
+
+// GetAllResources fetches all resources and formats them with consistent metadata
+func (c *Client) GetAllResources() ([]Resource, error) {
+	result, err := c.FetchResources()
+	if err != nil {
+		return nil, err
+	}
+	return result.Resources, nil
+}
+
+// FetchResources fetches all resources like GetAllResources, additionally
+// reporting the environment and resource type segments that failed to load
+func (c *Client) FetchResources() (*FetchResult, error) {
+	return c.FetchResourceTypes(ResourceTypes, nil)
+}
+
+// FetchResourceTypes fetches the resources of the given types in a single
+// walk of the environments. Connectors are listed from the fetched Kafka
+// clusters, or from the given ones when Kafka clusters aren't fetched. Only
+// fetching connectors doesn't list environments at all.
+func (c *Client) FetchResourceTypes(resourceTypes []string, kafkaClusters []Resource) (*FetchResult, error) {
+	wanted := make(map[string]bool, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		if !slices.Contains(ResourceTypes, resourceType) {
+			return nil, fmt.Errorf("unknown resource type %q", resourceType)
+		}
+		wanted[resourceType] = true
+	}
+
+	result := &FetchResult{Failed: make(map[Segment]error)}
+
+	if wanted["connector"] && !wanted["kafka"] {
+		for _, cluster := range kafkaClusters {
+			env := Environment{ID: cluster.Environment, Name: cluster.Labels["environment_name"]}
+			c.fetchConnectors(env, cluster, result)
+		}
+		log.Printf("Found %d connectors across %d Kafka clusters", len(result.Resources), len(kafkaClusters))
+		if len(wanted) == 1 {
+			return result, nil
+		}
+	}
+
+	environments, err := c.GetEnvironments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch environments: %w", err)
+	}
+
+	// Process each environment separately
+	for _, env := range environments {
+		log.Printf("Processing environment: %s (%s)", env.Name, env.ID)
+
+		if wanted["kafka"] {
+			for _, cluster := range c.fetchKafka(env, result) {
+				result.Resources = append(result.Resources, cluster)
+				if wanted["connector"] {
+					c.fetchConnectors(env, cluster, result)
+				}
+			}
+			if err, failed := result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "kafka"}]; failed && wanted["connector"] {
+				// Connectors are listed per cluster, so they fail along with the clusters
+				result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "connector"}] = err
+			}
+		}
+		if wanted["schema_registry"] {
+			c.fetchSchemaRegistries(env, result)
+		}
+		if wanted["ksql"] {
+			c.fetchKsqlDBs(env, result)
+		}
+		if wanted["compute_pool"] {
+			c.fetchComputePools(env, result)
+		}
+	}
+
+	log.Printf("Found %d total resources across %d environments", len(result.Resources), len(environments))
+	return result, nil
+}
+
+// fetchKafka returns the Kafka clusters of an environment, recording a failure in the result
+func (c *Client) fetchKafka(env Environment, result *FetchResult) []Resource {
+	kafkaClusters, err := c.GetKafkaClusters(env.ID)
+	if err != nil {
+		log.Printf("Warning: failed to fetch Kafka clusters for environment %s: %v", env.ID, err)
+		result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "kafka"}] = err
+		return nil
+	}
+
+	var clusters []Resource
+	for _, cluster := range kafkaClusters {
+		// Map cloud provider from cloud field
+		cloudProvider := cluster.Spec.Cloud
+		if cloudProvider == "" {
+			cloudProvider = "unknown"
+		}
+
+		clusters = append(clusters, Resource{
+			ID:           cluster.ID,
+			ResourceType: "kafka",
+			Environment:  env.ID,
+			Labels: map[string]string{
+				"cloud_provider":   cloudProvider,
+				"environment_name": env.Name,
+				"cluster_name":     cluster.Spec.DisplayName,
+				"region":           cluster.Spec.Region,
+			},
+			Endpoints: endpoints("bootstrap", cluster.Spec.KafkaBootstrapEndpoint),
+		})
+	}
+
+	return clusters
+}
+
+// fetchConnectors adds the connectors of a Kafka cluster to the result
+func (c *Client) fetchConnectors(env Environment, cluster Resource, result *FetchResult) {
+	connectors, err := c.GetConnectors(env.ID, cluster.ID)
+	if err != nil {
+		log.Printf("Warning: failed to fetch connectors for environment %s, cluster %s: %v",
+			env.ID, cluster.ID, err)
+		result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "connector", ClusterID: cluster.ID}] = err
+		return
+	}
+
+	for _, connector := range connectors {
+		result.Resources = append(result.Resources, Resource{
+			ID:           connector.ID,
+			ResourceType: "connector",
+			Environment:  env.ID,
+			Labels: map[string]string{
+				"cloud_provider":   cluster.Labels["cloud_provider"], // Use cluster's provider
+				"environment_name": env.Name,
+				"connector_name":   connector.ID,
+				"cluster_id":       connector.ClusterID,
+				"region":           cluster.Labels["region"],
+			},
+		})
+	}
+}
+
+// fetchSchemaRegistries adds the Schema Registry instances of an environment to the result
+func (c *Client) fetchSchemaRegistries(env Environment, result *FetchResult) {
+	schemaRegistries, err := c.GetSchemaRegistries(env.ID)
+	if err != nil {
+		log.Printf("Warning: failed to fetch Schema Registry instances for environment %s: %v", env.ID, err)
+		result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "schema_registry"}] = err
+		return
+	}
+
+	for _, sr := range schemaRegistries {
+		// Map cloud provider from cloud field
+		cloudProvider := sr.Spec.Cloud
+		if cloudProvider == "" {
+			cloudProvider = "unknown"
+		}
+
+		// Extract region information safely
+		var regionStr string
+		if regionVal, ok := sr.Spec.Region["id"]; ok {
+			if regionStr, ok = regionVal.(string); !ok {
+				regionStr = "unknown"
+			}
+		} else {
+			regionStr = "unknown"
+		}
+
+		// Create labels map
+		labels := map[string]string{
+			"cloud_provider":   cloudProvider,
+			"environment_name": env.Name,
+			"name":             sr.Spec.DisplayName,
+			"region":           regionStr,
+		}
+
+		// Add package if available
+		if sr.Spec.Package != "" {
+			labels["package"] = sr.Spec.Package
+		}
+
+		result.Resources = append(result.Resources, Resource{
+			ID:           sr.ID,
+			ResourceType: "schema_registry",
+			Environment:  env.ID,
+			Labels:       labels,
+			Endpoints:    endpoints("http", sr.Spec.HTTPEndpoint),
+		})
+	}
+}
+
+// fetchKsqlDBs adds the KSQL databases of an environment to the result
+func (c *Client) fetchKsqlDBs(env Environment, result *FetchResult) {
+	ksqlDBs, err := c.GetKsqlDBs(env.ID)
+	if err != nil {
+		log.Printf("Warning: failed to fetch KSQL databases for environment %s: %v", env.ID, err)
+		result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "ksql"}] = err
+		return
+	}
+
+	for _, ksql := range ksqlDBs {
+		// Map cloud provider from cloud field
+		cloudProvider := ksql.Spec.Cloud
+		if cloudProvider == "" {
+			cloudProvider = "unknown"
+		}
+
+		result.Resources = append(result.Resources, Resource{
+			ID:           ksql.ID,
+			ResourceType: "ksql",
+			Environment:  env.ID,
+			Labels: map[string]string{
+				"cloud_provider":   cloudProvider,
+				"environment_name": env.Name,
+				"name":             ksql.Spec.DisplayName,
+				"region":           ksql.Spec.Region,
+			},
+			Endpoints: endpoints("http", ksql.Spec.HTTPEndpoint),
+		})
+	}
+}
+
+// fetchComputePools adds the compute pools of an environment to the result
+func (c *Client) fetchComputePools(env Environment, result *FetchResult) {
+	computePools, err := c.GetComputePools(env.ID)
+	if err != nil {
+		log.Printf("Warning: failed to fetch compute pools for environment %s: %v", env.ID, err)
+		result.Failed[Segment{EnvironmentID: env.ID, ResourceType: "compute_pool"}] = err
+		return
+	}
+
+	for _, pool := range computePools {
+		// Map cloud provider from cloud field
+		cloudProvider := pool.Spec.Cloud
+		if cloudProvider == "" {
+			cloudProvider = "unknown"
+		}
+
+		result.Resources = append(result.Resources, Resource{
+			ID:           pool.ID,
+			ResourceType: "compute_pool",
+			Environment:  env.ID,
+			Labels: map[string]string{
+				"cloud_provider":   cloudProvider,
+				"environment_name": env.Name,
+				"name":             pool.Spec.DisplayName,
+				"region":           pool.Spec.Region,
+			},
+		})
+	}
+}
+
+// endpoints builds an endpoints map for a resource, or nil if the endpoint is unknown
+func endpoints(kind, endpoint string) map[string]string {
+	if endpoint == "" {
+		return nil
+	}
+	return map[string]string{kind: endpoint}
+}
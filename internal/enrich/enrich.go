@@ -0,0 +1,131 @@
+package enrich
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+// Match describes the labels a stage derived for a single resource
+type Match struct {
+	Rule   string            `json:"rule"`
+	Labels map[string]string `json:"labels"`
+	// Override lets the derived labels replace labels already on the resource
+	Override bool `json:"override,omitempty"`
+}
+
+// Stage derives additional labels for a resource
+type Stage interface {
+	// Name identifies the stage in debug output
+	Name() string
+	// Enrich returns the labels derived for the resource, or nil if nothing matched
+	Enrich(resource confluent.Resource) *Match
+}
+
+// Refresher is implemented by stages backed by external data that may change
+// between runs. Refresh is called once at the start of every pipeline run.
+type Refresher interface {
+	Refresh()
+}
+
+// ResourceMatch records which rule of a stage matched a resource
+type ResourceMatch struct {
+	Stage        string            `json:"stage"`
+	ResourceID   string            `json:"resource_id"`
+	ResourceType string            `json:"resource_type"`
+	Rule         string            `json:"rule"`
+	Labels       map[string]string `json:"labels"`
+}
+
+// Report summarises the most recent pipeline run
+type Report struct {
+	RunAt     time.Time       `json:"run_at"`
+	Resources int             `json:"resources"`
+	Matches   []ResourceMatch `json:"matches"`
+}
+
+// Pipeline applies enrichment stages to resources between fetching and formatting
+type Pipeline struct {
+	stages []Stage
+	mu     sync.RWMutex
+	report Report
+}
+
+// NewPipeline creates a pipeline running the given stages in order
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{
+		stages: stages,
+	}
+}
+
+// Run returns enriched copies of the resources. Labels already present on a
+// resource take precedence over derived labels unless the match overrides them.
+// The input is never modified, so cached resources can be passed directly.
+func (p *Pipeline) Run(resources []confluent.Resource) []confluent.Resource {
+	if p == nil || len(p.stages) == 0 {
+		return resources
+	}
+
+	for _, stage := range p.stages {
+		if refresher, ok := stage.(Refresher); ok {
+			refresher.Refresh()
+		}
+	}
+
+	report := Report{
+		RunAt:     time.Now(),
+		Resources: len(resources),
+	}
+
+	enriched := make([]confluent.Resource, len(resources))
+	for i, resource := range resources {
+		// Copy labels so the cached resource is left untouched
+		labels := make(map[string]string, len(resource.Labels))
+		for k, v := range resource.Labels {
+			labels[k] = v
+		}
+		resource.Labels = labels
+
+		for _, stage := range p.stages {
+			match := stage.Enrich(resource)
+			if match == nil {
+				continue
+			}
+
+			for k, v := range match.Labels {
+				if _, exists := labels[k]; !exists || match.Override {
+					labels[k] = v
+				}
+			}
+
+			report.Matches = append(report.Matches, ResourceMatch{
+				Stage:        stage.Name(),
+				ResourceID:   resource.ID,
+				ResourceType: resource.ResourceType,
+				Rule:         match.Rule,
+				Labels:       match.Labels,
+			})
+		}
+
+		enriched[i] = resource
+	}
+
+	p.mu.Lock()
+	p.report = report
+	p.mu.Unlock()
+
+	return enriched
+}
+
+// LastReport returns the report of the most recent run
+func (p *Pipeline) LastReport() Report {
+	if p == nil {
+		return Report{}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.report
+}
@@ -0,0 +1,111 @@
+package enrich
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+type compiledNameRule struct {
+	resourceType string
+	source       string
+	pattern      *regexp.Regexp
+}
+
+// NameParser is a stage that parses display names into labels
+type NameParser struct {
+	rules []compiledNameRule
+}
+
+// NewNameParser compiles the given rules. Rules are tried in order and the
+// first matching rule for a resource wins.
+func NewNameParser(rules []config.NameRule) (*NameParser, error) {
+	parser := &NameParser{}
+
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("name rule %d: pattern is required", i)
+		}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("name rule %d: invalid pattern: %w", i, err)
+		}
+
+		hasNamedGroup := false
+		for _, name := range pattern.SubexpNames() {
+			if name != "" {
+				hasNamedGroup = true
+				break
+			}
+		}
+		if !hasNamedGroup {
+			return nil, fmt.Errorf("name rule %d: pattern has no named capture groups", i)
+		}
+
+		parser.rules = append(parser.rules, compiledNameRule{
+			resourceType: rule.ResourceType,
+			source:       rule.Source,
+			pattern:      pattern,
+		})
+	}
+
+	return parser, nil
+}
+
+// Name identifies the stage in debug output
+func (p *NameParser) Name() string {
+	return "name_rules"
+}
+
+// Enrich applies the first matching rule to the resource
+func (p *NameParser) Enrich(resource confluent.Resource) *Match {
+	for _, rule := range p.rules {
+		if rule.resourceType != "" && rule.resourceType != resource.ResourceType {
+			continue
+		}
+
+		source := rule.source
+		if source == "" {
+			source = defaultNameLabel(resource.ResourceType)
+		}
+
+		value, ok := resource.Labels[source]
+		if !ok {
+			continue
+		}
+
+		submatches := rule.pattern.FindStringSubmatch(value)
+		if submatches == nil {
+			continue
+		}
+
+		labels := make(map[string]string)
+		for i, name := range rule.pattern.SubexpNames() {
+			if name != "" && submatches[i] != "" {
+				labels[name] = submatches[i]
+			}
+		}
+
+		return &Match{
+			Rule:   fmt.Sprintf("%s =~ %s", source, rule.pattern.String()),
+			Labels: labels,
+		}
+	}
+
+	return nil
+}
+
+// defaultNameLabel returns the label holding the display name for a resource type
+func defaultNameLabel(resourceType string) string {
+	switch resourceType {
+	case "kafka":
+		return "cluster_name"
+	case "connector":
+		return "connector_name"
+	default:
+		return "name"
+	}
+}
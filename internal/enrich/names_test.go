@@ -0,0 +1,95 @@
+package enrich
+
+import (
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestNameParserEnrich(t *testing.T) {
+	parser, err := NewNameParser([]config.NameRule{
+		{
+			ResourceType: "kafka",
+			Pattern:      `^(?P<team>[a-z]+)-(?P<stage>prod|staging|dev)-(?P<region_code>[a-z0-9]+)$`,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile name rules: %v", err)
+	}
+
+	match := parser.Enrich(confluent.Resource{
+		ID:           "lkc-abc123",
+		ResourceType: "kafka",
+		Labels:       map[string]string{"cluster_name": "payments-prod-euw1"},
+	})
+	if match == nil {
+		t.Fatal("Expected the rule to match, but it did not")
+	}
+
+	expected := map[string]string{"team": "payments", "stage": "prod", "region_code": "euw1"}
+	for k, v := range expected {
+		if match.Labels[k] != v {
+			t.Errorf("Expected label %s='%s', got '%s'", k, v, match.Labels[k])
+		}
+	}
+
+	// Rules are scoped to their resource type
+	match = parser.Enrich(confluent.Resource{
+		ID:           "lsrc-abc123",
+		ResourceType: "schema_registry",
+		Labels:       map[string]string{"name": "payments-prod-euw1"},
+	})
+	if match != nil {
+		t.Errorf("Expected no match for a different resource type, got %v", match)
+	}
+}
+
+func TestNameParserInvalidRules(t *testing.T) {
+	if _, err := NewNameParser([]config.NameRule{{Pattern: "("}}); err == nil {
+		t.Error("Expected an error for an invalid pattern, got nil")
+	}
+
+	if _, err := NewNameParser([]config.NameRule{{Pattern: "^([a-z]+)$"}}); err == nil {
+		t.Error("Expected an error for a pattern without named groups, got nil")
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	parser, err := NewNameParser([]config.NameRule{
+		{Pattern: `^(?P<team>[a-z]+)-(?P<region>[a-z0-9]+)$`},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile name rules: %v", err)
+	}
+
+	resources := []confluent.Resource{
+		{
+			ID:           "lksqlc-abc123",
+			ResourceType: "ksql",
+			Labels:       map[string]string{"name": "payments-euw1", "region": "eu-west-1"},
+		},
+	}
+
+	pipeline := NewPipeline(parser)
+	enriched := pipeline.Run(resources)
+
+	if enriched[0].Labels["team"] != "payments" {
+		t.Errorf("Expected team label 'payments', got '%s'", enriched[0].Labels["team"])
+	}
+
+	// Existing labels take precedence over derived ones
+	if enriched[0].Labels["region"] != "eu-west-1" {
+		t.Errorf("Expected region label to be preserved, got '%s'", enriched[0].Labels["region"])
+	}
+
+	// The input resources must not be modified
+	if _, found := resources[0].Labels["team"]; found {
+		t.Error("Expected input resources to be left untouched")
+	}
+
+	report := pipeline.LastReport()
+	if len(report.Matches) != 1 || report.Matches[0].ResourceID != "lksqlc-abc123" {
+		t.Errorf("Expected one match for lksqlc-abc123 in report, got %v", report.Matches)
+	}
+}
@@ -0,0 +1,204 @@
+package enrich
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+const (
+	// PrecedenceConfluent keeps Confluent-derived labels on conflict
+	PrecedenceConfluent = "confluent"
+	// PrecedenceFile lets the ownership file override Confluent-derived labels
+	PrecedenceFile = "file"
+)
+
+// OwnershipEntry maps a resource ID or display name glob to extra labels
+type OwnershipEntry struct {
+	Match  string            `yaml:"match"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// Ownership is a stage that merges labels from an external inventory file.
+// The file is reloaded whenever its modification time or size changes.
+type Ownership struct {
+	file     string
+	override bool
+
+	mu      sync.RWMutex
+	entries []OwnershipEntry
+	modTime time.Time
+	size    int64
+}
+
+// NewOwnership loads the ownership file described by the configuration
+func NewOwnership(cfg config.OwnershipConfig) (*Ownership, error) {
+	o := &Ownership{
+		file: cfg.File,
+	}
+
+	switch cfg.Precedence {
+	case "", PrecedenceConfluent:
+	case PrecedenceFile:
+		o.override = true
+	default:
+		return nil, fmt.Errorf("invalid ownership precedence %q, must be %q or %q", cfg.Precedence, PrecedenceConfluent, PrecedenceFile)
+	}
+
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Name identifies the stage in debug output
+func (o *Ownership) Name() string {
+	return "ownership"
+}
+
+// Refresh reloads the ownership file if it changed on disk. On failure the
+// previously loaded entries are kept.
+func (o *Ownership) Refresh() {
+	info, err := os.Stat(o.file)
+	if err != nil {
+		log.Printf("Warning: failed to stat ownership file %s: %v", o.file, err)
+		return
+	}
+
+	o.mu.RLock()
+	changed := !info.ModTime().Equal(o.modTime) || info.Size() != o.size
+	o.mu.RUnlock()
+
+	if !changed {
+		return
+	}
+
+	if err := o.load(); err != nil {
+		log.Printf("Warning: failed to reload ownership file, keeping previous entries: %v", err)
+		return
+	}
+
+	log.Printf("Reloaded ownership file %s", o.file)
+}
+
+// Enrich returns the labels of the first entry matching the resource ID or display name
+func (o *Ownership) Enrich(resource confluent.Resource) *Match {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	name := resource.Labels[defaultNameLabel(resource.ResourceType)]
+
+	for _, entry := range o.entries {
+		if globMatch(entry.Match, resource.ID) || (name != "" && globMatch(entry.Match, name)) {
+			return &Match{
+				Rule:     entry.Match,
+				Labels:   entry.Labels,
+				Override: o.override,
+			}
+		}
+	}
+
+	return nil
+}
+
+// load reads and parses the ownership file, replacing the current entries
+func (o *Ownership) load() error {
+	info, err := os.Stat(o.file)
+	if err != nil {
+		return fmt.Errorf("failed to stat ownership file %s: %w", o.file, err)
+	}
+
+	data, err := os.ReadFile(o.file)
+	if err != nil {
+		return fmt.Errorf("failed to read ownership file %s: %w", o.file, err)
+	}
+
+	var entries []OwnershipEntry
+	if strings.EqualFold(filepath.Ext(o.file), ".csv") {
+		entries, err = parseOwnershipCSV(string(data))
+	} else {
+		err = yaml.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse ownership file %s: %w", o.file, err)
+	}
+
+	for i, entry := range entries {
+		if entry.Match == "" {
+			return fmt.Errorf("ownership file %s: entry %d has no match", o.file, i)
+		}
+		if _, err := path.Match(entry.Match, ""); err != nil {
+			return fmt.Errorf("ownership file %s: entry %d has invalid glob %q: %w", o.file, i, entry.Match, err)
+		}
+	}
+
+	o.mu.Lock()
+	o.entries = entries
+	o.modTime = info.ModTime()
+	o.size = info.Size()
+	o.mu.Unlock()
+
+	return nil
+}
+
+// parseOwnershipCSV parses a CSV file with a header row. The "match" column
+// holds the ID or glob and every other column becomes a label.
+func parseOwnershipCSV(data string) ([]OwnershipEntry, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	matchColumn := -1
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+		if header[i] == "match" {
+			matchColumn = i
+		}
+	}
+	if matchColumn == -1 {
+		return nil, fmt.Errorf("missing 'match' column in header")
+	}
+
+	entries := make([]OwnershipEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		entry := OwnershipEntry{
+			Match:  strings.TrimSpace(record[matchColumn]),
+			Labels: make(map[string]string),
+		}
+
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if i != matchColumn && value != "" {
+				entry.Labels[header[i]] = value
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// globMatch reports whether value matches the shell-style pattern
+func globMatch(pattern, value string) bool {
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
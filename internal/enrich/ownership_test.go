@@ -0,0 +1,114 @@
+package enrich
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestOwnershipYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.yaml")
+	writeFile(t, path, `
+- match: lkc-abc123
+  labels:
+    owner: alice
+    team: payments
+- match: "orders-*"
+  labels:
+    team: orders
+    cost_center: "4711"
+`)
+
+	ownership, err := NewOwnership(config.OwnershipConfig{File: path})
+	if err != nil {
+		t.Fatalf("Failed to load ownership file: %v", err)
+	}
+
+	// Match by resource ID
+	match := ownership.Enrich(confluent.Resource{ID: "lkc-abc123", ResourceType: "kafka"})
+	if match == nil || match.Labels["owner"] != "alice" {
+		t.Errorf("Expected owner 'alice' for lkc-abc123, got %v", match)
+	}
+
+	// Match by display name glob
+	match = ownership.Enrich(confluent.Resource{
+		ID:           "lkc-def456",
+		ResourceType: "kafka",
+		Labels:       map[string]string{"cluster_name": "orders-prod"},
+	})
+	if match == nil || match.Labels["cost_center"] != "4711" {
+		t.Errorf("Expected cost_center '4711' for orders-prod, got %v", match)
+	}
+
+	if match.Override {
+		t.Error("Expected Confluent labels to take precedence by default")
+	}
+}
+
+func TestOwnershipCSVReloadAndPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	writeFile(t, path, "match,owner,pagerduty_service\nlcc-*,bob,PABC123\n")
+
+	ownership, err := NewOwnership(config.OwnershipConfig{File: path, Precedence: PrecedenceFile})
+	if err != nil {
+		t.Fatalf("Failed to load ownership file: %v", err)
+	}
+
+	resources := []confluent.Resource{
+		{ID: "lcc-abc123", ResourceType: "connector", Labels: map[string]string{"owner": "confluent"}},
+	}
+
+	pipeline := NewPipeline(ownership)
+	enriched := pipeline.Run(resources)
+
+	if enriched[0].Labels["owner"] != "bob" {
+		t.Errorf("Expected file precedence to set owner 'bob', got '%s'", enriched[0].Labels["owner"])
+	}
+	if enriched[0].Labels["pagerduty_service"] != "PABC123" {
+		t.Errorf("Expected pagerduty_service 'PABC123', got '%s'", enriched[0].Labels["pagerduty_service"])
+	}
+
+	// Rewrite the file and make sure the change is picked up on the next run
+	writeFile(t, path, "match,owner,pagerduty_service\nlcc-*,carol,PDEF456\n")
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Failed to update modification time: %v", err)
+	}
+
+	enriched = pipeline.Run(resources)
+	if enriched[0].Labels["owner"] != "carol" {
+		t.Errorf("Expected reloaded owner 'carol', got '%s'", enriched[0].Labels["owner"])
+	}
+
+	// A broken file keeps the previous entries
+	writeFile(t, path, "owner\n")
+	past := future.Add(time.Minute)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("Failed to update modification time: %v", err)
+	}
+
+	enriched = pipeline.Run(resources)
+	if enriched[0].Labels["owner"] != "carol" {
+		t.Errorf("Expected previous owner 'carol' to be kept, got '%s'", enriched[0].Labels["owner"])
+	}
+}
+
+func TestOwnershipInvalidPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.yaml")
+	writeFile(t, path, "[]")
+
+	if _, err := NewOwnership(config.OwnershipConfig{File: path, Precedence: "sometimes"}); err == nil {
+		t.Error("Expected an error for an invalid precedence, got nil")
+	}
+}
@@ -0,0 +1,278 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// DefaultURL is the Confluent Cloud Metrics API export endpoint
+	DefaultURL = "https://api.telemetry.confluent.cloud/v2/metrics/cloud/export"
+
+	defaultTimeout = 30 * time.Second
+)
+
+// ContentType is the content type of the proxied metrics
+var ContentType = string(expfmt.NewFormat(expfmt.TypeTextPlain))
+
+// UpstreamError is returned when the export endpoint responds with an error status
+type UpstreamError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("export endpoint returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Proxy fetches metrics from the export endpoint with the service's
+// credentials and adds the labels of the matching cached resources
+type Proxy struct {
+	httpClient   *http.Client
+	url          *url.URL
+	apiKey       string
+	apiSecret    string
+	inv          *inventory.Inventory
+	labelBuilder *labels.Builder
+	limits       targets.BatchLimits
+}
+
+// NewProxy creates an export proxy, filling in defaults for unset options
+func NewProxy(cfg config.ExportConfig, apiKey, apiSecret string, inv *inventory.Inventory, labelBuilder *labels.Builder) (*Proxy, error) {
+	exportURL := cfg.URL
+	if exportURL == "" {
+		exportURL = DefaultURL
+	}
+	parsedURL, err := url.Parse(exportURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export url %q: %w", exportURL, err)
+	}
+
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		parsed, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid export timeout %q: %w", cfg.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	limits := targets.BatchLimits(cfg.BatchLimits)
+	if err := limits.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid export configuration: %w", err)
+	}
+
+	return &Proxy{
+		httpClient:   &http.Client{Timeout: timeout},
+		url:          parsedURL,
+		apiKey:       apiKey,
+		apiSecret:    apiSecret,
+		inv:          inv,
+		labelBuilder: labelBuilder,
+		limits:       limits,
+	}, nil
+}
+
+// Export forwards the query to the export endpoint and returns the metrics in
+// the text exposition format, enriched with resource labels. Queries with more
+// resource IDs than the batch limits allow are split into several requests.
+func (p *Proxy) Export(ctx context.Context, query url.Values) ([]byte, error) {
+	families := make(map[string]*dto.MetricFamily)
+
+	for _, chunk := range chunkQuery(query, p.limits) {
+		chunkFamilies, err := p.fetch(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		mergeFamilies(families, chunkFamilies)
+	}
+
+	resources, err := p.inv.Resources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resources: %w", err)
+	}
+
+	byID := make(map[string]confluent.Resource, len(resources))
+	for _, resource := range resources {
+		byID[resource.ID] = resource
+	}
+
+	return p.encode(families, byID)
+}
+
+// chunkQuery splits the resource.<type>.id parameters of a query into
+// requests within the batch limits. Other parameters are sent with every request.
+func chunkQuery(query url.Values, limits targets.BatchLimits) []url.Values {
+	base := url.Values{}
+	idParams := make(map[string][]string)
+	for key, values := range query {
+		if resourceType, ok := resourceIDParam(key); ok {
+			idParams[resourceType] = append(idParams[resourceType], values...)
+		} else {
+			base[key] = values
+		}
+	}
+
+	if len(idParams) == 0 {
+		return []url.Values{query}
+	}
+
+	var queries []url.Values
+	for _, resourceType := range targets.ResourceTypes {
+		for _, chunk := range targets.Chunk(idParams[resourceType], limits.For(resourceType)) {
+			chunkQuery := url.Values{}
+			for key, values := range base {
+				chunkQuery[key] = values
+			}
+			chunkQuery["resource."+resourceType+".id"] = chunk
+			queries = append(queries, chunkQuery)
+		}
+	}
+	return queries
+}
+
+// resourceIDParam returns the resource type of a resource.<type>.id parameter
+func resourceIDParam(key string) (string, bool) {
+	if !strings.HasPrefix(key, "resource.") || !strings.HasSuffix(key, ".id") {
+		return "", false
+	}
+	resourceType := strings.TrimSuffix(strings.TrimPrefix(key, "resource."), ".id")
+	return resourceType, targets.IsResourceType(resourceType)
+}
+
+// mergeFamilies appends the metrics of the source families to the destination
+func mergeFamilies(dst, src map[string]*dto.MetricFamily) {
+	for name, family := range src {
+		if existing, ok := dst[name]; ok {
+			existing.Metric = append(existing.Metric, family.Metric...)
+		} else {
+			dst[name] = family
+		}
+	}
+}
+
+// fetch requests the metrics from the export endpoint and parses them
+func (p *Proxy) fetch(ctx context.Context, query url.Values) (map[string]*dto.MetricFamily, error) {
+	// Keep any query parameters of the configured URL
+	requestURL := *p.url
+	merged := requestURL.Query()
+	for key, values := range query {
+		for _, value := range values {
+			merged.Add(key, value)
+		}
+	}
+	requestURL.RawQuery = merged.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(p.apiKey, p.apiSecret)
+	req.Header.Set("Accept", ContentType)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request export endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &UpstreamError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse export response: %w", err)
+	}
+
+	return families, nil
+}
+
+// encode adds resource labels to every metric and writes the families in sorted order
+func (p *Proxy) encode(families map[string]*dto.MetricFamily, byID map[string]confluent.Resource) ([]byte, error) {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		family := families[name]
+		for _, metric := range family.Metric {
+			p.addResourceLabels(metric, byID)
+		}
+
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return nil, fmt.Errorf("failed to encode metric family %s: %w", name, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// addResourceLabels adds the labels of the resource a metric belongs to.
+// Labels already set by the export endpoint are never overwritten.
+func (p *Proxy) addResourceLabels(metric *dto.Metric, byID map[string]confluent.Resource) {
+	existing := make(map[string]bool, len(metric.Label))
+	var resource confluent.Resource
+	found := false
+
+	for _, pair := range metric.Label {
+		existing[pair.GetName()] = true
+		// Export labels identify resources as <type>_id, e.g. kafka_id
+		if !found && isResourceIDLabel(pair.GetName()) {
+			resource, found = byID[pair.GetValue()]
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	resourceLabels := p.labelBuilder.Build(resource)
+	keys := make([]string, 0, len(resourceLabels))
+	for key := range resourceLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if existing[key] {
+			continue
+		}
+		metric.Label = append(metric.Label, &dto.LabelPair{
+			Name:  proto.String(key),
+			Value: proto.String(resourceLabels[key]),
+		})
+	}
+
+	sort.Slice(metric.Label, func(i, j int) bool {
+		return metric.Label[i].GetName() < metric.Label[j].GetName()
+	})
+}
+
+// isResourceIDLabel reports whether the label holds a resource ID, e.g. kafka_id
+func isResourceIDLabel(name string) bool {
+	return strings.HasSuffix(name, "_id") && targets.IsResourceType(strings.TrimSuffix(name, "_id"))
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const exportResponse = `# HELP confluent_kafka_server_received_bytes The delta count of bytes received.
+# TYPE confluent_kafka_server_received_bytes gauge
+confluent_kafka_server_received_bytes{kafka_id="lkc-abc123",topic="orders"} 42.0 1700000000000
+confluent_kafka_server_received_bytes{kafka_id="lkc-unknown",topic="orders"} 7.0 1700000000000
+`
+
+func TestProxyExport(t *testing.T) {
+	var gotQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "key" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		gotQuery = r.URL.Query()
+		w.Write([]byte(exportResponse))
+	}))
+	defer server.Close()
+
+	proxy, err := NewProxy(config.ExportConfig{URL: server.URL + "/export?tenant=a"}, "key", "secret", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	query := url.Values{"resource.kafka.id": {"lkc-abc123", "lkc-unknown"}}
+	families, err := proxy.fetch(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Failed to fetch metrics: %v", err)
+	}
+
+	if len(gotQuery["resource.kafka.id"]) != 2 {
+		t.Errorf("Expected resource IDs to be forwarded, got %v", gotQuery)
+	}
+	if gotQuery.Get("tenant") != "a" {
+		t.Errorf("Expected the query of the configured URL to be kept, got %v", gotQuery)
+	}
+
+	byID := map[string]confluent.Resource{
+		"lkc-abc123": {
+			ID:           "lkc-abc123",
+			ResourceType: "kafka",
+			Labels:       map[string]string{"cluster_name": "orders", "topic": "ignored"},
+		},
+	}
+
+	body, err := proxy.encode(families, byID)
+	if err != nil {
+		t.Fatalf("Failed to encode metrics: %v", err)
+	}
+
+	expected := `# HELP confluent_kafka_server_received_bytes The delta count of bytes received.
+# TYPE confluent_kafka_server_received_bytes gauge
+confluent_kafka_server_received_bytes{cluster_name="orders",kafka_id="lkc-abc123",topic="orders"} 42 1700000000000
+confluent_kafka_server_received_bytes{kafka_id="lkc-unknown",topic="orders"} 7 1700000000000
+`
+	if string(body) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, body)
+	}
+}
+
+func TestProxyUpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	proxy, err := NewProxy(config.ExportConfig{URL: server.URL}, "key", "secret", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	_, err = proxy.fetch(context.Background(), url.Values{})
+	upstreamErr, ok := err.(*UpstreamError)
+	if !ok {
+		t.Fatalf("Expected UpstreamError, got %v", err)
+	}
+	if upstreamErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", upstreamErr.StatusCode)
+	}
+}
+
+func TestChunkQuery(t *testing.T) {
+	query := url.Values{
+		"resource.kafka.id":     {"lkc-c", "lkc-a", "lkc-b"},
+		"resource.connector.id": {"lcc-a"},
+		"metric":                {"io.confluent.kafka.server/received_bytes"},
+	}
+
+	queries := chunkQuery(query, targets.BatchLimits{"kafka": 2})
+
+	expected := []url.Values{
+		{"resource.connector.id": {"lcc-a"}, "metric": query["metric"]},
+		{"resource.kafka.id": {"lkc-a", "lkc-b"}, "metric": query["metric"]},
+		{"resource.kafka.id": {"lkc-c"}, "metric": query["metric"]},
+	}
+	if !reflect.DeepEqual(queries, expected) {
+		t.Errorf("Expected %v, got %v", expected, queries)
+	}
+}
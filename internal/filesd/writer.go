@@ -0,0 +1,295 @@
+package filesd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// FormatJSON writes file_sd files as JSON
+	FormatJSON = "json"
+	// FormatYAML writes file_sd files as YAML
+	FormatYAML = "yaml"
+)
+
+var (
+	// validJobName keeps job names safe to use as file names
+	validJobName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// fileTarget is a file_sd target group. Prometheus rejects file_sd groups
+// with fields other than targets and labels, so scrape parameters are passed
+// as __param_ labels instead.
+type fileTarget struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+// Job is a single target file with its own target assignment
+type Job struct {
+	Name          string
+	ResourceTypes map[string]bool
+	Assignment    targets.Assignment
+	Prefix        string
+}
+
+// Writer periodically writes Prometheus file_sd target files to a directory
+type Writer struct {
+	inv          *inventory.Inventory
+	labelBuilder *labels.Builder
+	directory    string
+	format       string
+	jobs         []Job
+}
+
+// NewWriter creates a writer from the file_sd configuration. Jobs without
+// targets of their own inherit the defaults of the file_sd section.
+func NewWriter(inv *inventory.Inventory, labelBuilder *labels.Builder, cfg config.FileSDConfig, profiles map[string]config.TargetProfile) (*Writer, error) {
+	if cfg.Directory == "" {
+		return nil, fmt.Errorf("file_sd directory is required")
+	}
+
+	format := cfg.Format
+	switch format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatYAML:
+	default:
+		return nil, fmt.Errorf("invalid file_sd format %q, must be %q or %q", format, FormatJSON, FormatYAML)
+	}
+
+	jobConfigs := cfg.Jobs
+	if len(jobConfigs) == 0 {
+		// Default to one file per resource type
+		for _, resourceType := range targets.ResourceTypes {
+			jobConfigs = append(jobConfigs, config.FileSDJob{
+				Name:          resourceType,
+				ResourceTypes: []string{resourceType},
+			})
+		}
+	}
+
+	w := &Writer{
+		inv:          inv,
+		labelBuilder: labelBuilder,
+		directory:    cfg.Directory,
+		format:       format,
+	}
+
+	for _, jobConfig := range jobConfigs {
+		job, err := newJob(jobConfig, cfg, profiles)
+		if err != nil {
+			return nil, err
+		}
+		w.jobs = append(w.jobs, job)
+	}
+
+	return w, nil
+}
+
+// newJob resolves the targets and prefix of a job against the file_sd defaults
+func newJob(jobConfig config.FileSDJob, defaults config.FileSDConfig, profiles map[string]config.TargetProfile) (Job, error) {
+	if !validJobName.MatchString(jobConfig.Name) {
+		return Job{}, fmt.Errorf("invalid file_sd job name %q, must contain only alphanumeric characters, hyphens and underscores", jobConfig.Name)
+	}
+
+	job := Job{
+		Name:          jobConfig.Name,
+		ResourceTypes: make(map[string]bool),
+	}
+
+	for _, resourceType := range jobConfig.ResourceTypes {
+		if !targets.IsResourceType(resourceType) {
+			return Job{}, fmt.Errorf("file_sd job %s: unknown resource type %q", job.Name, resourceType)
+		}
+		job.ResourceTypes[resourceType] = true
+	}
+
+	// Jobs without targets of their own use the file_sd defaults
+	profileName, fallback := jobConfig.Profile, jobConfig.Targets
+	if profileName == "" && len(fallback) == 0 {
+		profileName, fallback = defaults.Profile, defaults.Targets
+	}
+
+	if profileName != "" {
+		profile, ok := profiles[profileName]
+		if !ok {
+			return Job{}, fmt.Errorf("file_sd job %s: unknown target profile %q", job.Name, profileName)
+		}
+		job.Assignment = targets.FromProfile(profile)
+	}
+
+	if len(fallback) > 0 {
+		job.Assignment.Fallback = fallback
+	}
+
+	if job.Assignment.Empty() {
+		return Job{}, fmt.Errorf("file_sd job %s: no targets configured", job.Name)
+	}
+
+	prefix := jobConfig.Prefix
+	if prefix == "" {
+		prefix = defaults.Prefix
+	}
+	prefix, err := targets.ParsePrefix(prefix)
+	if err != nil {
+		return Job{}, fmt.Errorf("file_sd job %s: %w", job.Name, err)
+	}
+	job.Prefix = prefix
+
+	return job, nil
+}
+
+// Run writes the target files immediately and then on every interval until the context is done
+func (w *Writer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.WriteOnce(); err != nil {
+			log.Printf("Failed to write file_sd targets: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WriteOnce refreshes the resources and writes every job's target file.
+// Existing files are kept when the refresh fails.
+func (w *Writer) WriteOnce() error {
+	if err := w.inv.Refresh(); err != nil {
+		return fmt.Errorf("failed to refresh resources: %w", err)
+	}
+
+	resources, err := w.inv.Resources()
+	if err != nil {
+		return fmt.Errorf("failed to load resources: %w", err)
+	}
+
+	for _, job := range w.jobs {
+		if err := w.writeJob(job, resources); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeJob renders a job's targets and writes them if they changed
+func (w *Writer) writeJob(job Job, resources []confluent.Resource) error {
+	var selected []confluent.Resource
+	for _, resource := range resources {
+		if len(job.ResourceTypes) == 0 || job.ResourceTypes[resource.ResourceType] {
+			selected = append(selected, resource)
+		}
+	}
+
+	groups := fileTargets(targets.Format(selected, job.Assignment, job.Prefix, w.labelBuilder))
+	content, err := w.encode(groups)
+	if err != nil {
+		return fmt.Errorf("failed to encode targets for job %s: %w", job.Name, err)
+	}
+
+	path := filepath.Join(w.directory, job.Name+"."+w.format)
+	changed, err := writeIfChanged(path, content)
+	if err != nil {
+		return fmt.Errorf("failed to write targets for job %s: %w", job.Name, err)
+	}
+
+	if changed {
+		log.Printf("Wrote %d targets to %s", len(groups), path)
+	}
+
+	return nil
+}
+
+// fileTargets converts target groups to file_sd groups, moving the scrape
+// parameters into __param_ labels
+func fileTargets(groups []targets.Target) []fileTarget {
+	// Write an empty list rather than null
+	fileGroups := make([]fileTarget, 0, len(groups))
+	for _, group := range groups {
+		groupLabels := make(map[string]string, len(group.Labels)+len(group.Params))
+		for name, value := range group.Labels {
+			groupLabels[name] = value
+		}
+		for name, values := range group.Params {
+			if len(values) > 0 {
+				groupLabels[model.ParamLabelPrefix+name] = values[0]
+			}
+		}
+		fileGroups = append(fileGroups, fileTarget{Targets: group.Targets, Labels: groupLabels})
+	}
+	return fileGroups
+}
+
+// encode serialises the target groups in the configured format
+func (w *Writer) encode(groups []fileTarget) ([]byte, error) {
+	if w.format == FormatYAML {
+		return yaml.Marshal(groups)
+	}
+
+	content, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// writeIfChanged atomically replaces the file with the content unless it
+// already holds exactly that content
+func writeIfChanged(path string, content []byte) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmpName, 0o644); err != nil {
+		return false, err
+	}
+
+	// Rename is atomic, so Prometheus never reads a partially written file
+	if err := os.Rename(tmpName, path); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
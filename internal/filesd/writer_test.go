@@ -0,0 +1,159 @@
+package filesd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	yamlv2 "go.yaml.in/yaml/v2"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+func TestWriteIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kafka.json")
+
+	changed, err := writeIfChanged(path, []byte("[]\n"))
+	if err != nil || !changed {
+		t.Fatalf("Expected first write to change the file, got changed=%v, err=%v", changed, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat written file: %v", err)
+	}
+
+	// Identical content must not rewrite the file
+	changed, err = writeIfChanged(path, []byte("[]\n"))
+	if err != nil || changed {
+		t.Errorf("Expected identical content to be skipped, got changed=%v, err=%v", changed, err)
+	}
+
+	unchanged, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat written file: %v", err)
+	}
+	if !os.SameFile(info, unchanged) {
+		t.Error("Expected file to be left in place when content is unchanged")
+	}
+
+	changed, err = writeIfChanged(path, []byte("[{}]\n"))
+	if err != nil || !changed {
+		t.Errorf("Expected new content to be written, got changed=%v, err=%v", changed, err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "[{}]\n" {
+		t.Errorf("Expected updated content, got %q", content)
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the target file in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestNewWriterJobs(t *testing.T) {
+	profiles := map[string]config.TargetProfile{
+		"connect": {Targets: []string{"connect-health:9100"}},
+	}
+
+	writer, err := NewWriter(nil, nil, config.FileSDConfig{
+		Directory: t.TempDir(),
+		Targets:   []string{"api.telemetry.confluent.cloud"},
+		Prefix:    "confluent",
+		Jobs: []config.FileSDJob{
+			{Name: "confluent-cloud", ResourceTypes: []string{"kafka", "schema_registry"}},
+			{Name: "connectors", ResourceTypes: []string{"connector"}, Profile: "connect"},
+		},
+	}, profiles)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	if len(writer.jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %d", len(writer.jobs))
+	}
+
+	if writer.jobs[0].Prefix != "confluent_" {
+		t.Errorf("Expected inherited prefix 'confluent_', got '%s'", writer.jobs[0].Prefix)
+	}
+
+	if !reflect.DeepEqual(writer.jobs[1].Assignment.ForType("connector"), []string{"connect-health:9100"}) {
+		t.Errorf("Expected profile targets for connectors, got %v", writer.jobs[1].Assignment.ForType("connector"))
+	}
+
+	// Without jobs one file per resource type is written
+	writer, err = NewWriter(nil, nil, config.FileSDConfig{
+		Directory: t.TempDir(),
+		Format:    FormatYAML,
+		Targets:   []string{"api.telemetry.confluent.cloud"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if len(writer.jobs) != 5 {
+		t.Errorf("Expected one job per resource type, got %d", len(writer.jobs))
+	}
+}
+
+func TestNewWriterInvalid(t *testing.T) {
+	invalid := []config.FileSDConfig{
+		{Targets: []string{"host:443"}},
+		{Directory: "/tmp", Targets: []string{"host:443"}, Format: "toml"},
+		{Directory: "/tmp"},
+		{Directory: "/tmp", Targets: []string{"host:443"}, Jobs: []config.FileSDJob{{Name: "../escape"}}},
+		{Directory: "/tmp", Targets: []string{"host:443"}, Jobs: []config.FileSDJob{{Name: "job", ResourceTypes: []string{"flink"}}}},
+		{Directory: "/tmp", Jobs: []config.FileSDJob{{Name: "job", Profile: "missing"}}},
+	}
+
+	for _, cfg := range invalid {
+		if _, err := NewWriter(nil, nil, cfg, nil); err == nil {
+			t.Errorf("Expected an error for %+v, got nil", cfg)
+		}
+	}
+}
+
+func TestEncodeParsesAsFileSD(t *testing.T) {
+	resources := []confluent.Resource{
+		{ID: "lkc-a", ResourceType: "kafka", Labels: map[string]string{"cluster_name": "orders"}},
+	}
+	groups := fileTargets(targets.Format(resources, targets.Assignment{Fallback: []string{"api.telemetry.confluent.cloud"}}, "", labels.NewBuilder(nil, nil)))
+
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		content, err := (&Writer{format: format}).encode(groups)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", format, err)
+		}
+
+		// Prometheus decodes file_sd files strictly, as in discovery/file
+		var parsed []*targetgroup.Group
+		if format == FormatYAML {
+			err = yamlv2.UnmarshalStrict(content, &parsed)
+		} else {
+			err = json.Unmarshal(content, &parsed)
+		}
+		if err != nil {
+			t.Fatalf("Expected Prometheus to parse the %s output, got %v", format, err)
+		}
+
+		if len(parsed) != 1 || len(parsed[0].Targets) != 1 {
+			t.Fatalf("Expected 1 group with 1 target, got %+v", parsed)
+		}
+		if id := parsed[0].Labels[model.ParamLabelPrefix+"resource.kafka.id"]; id != "lkc-a" {
+			t.Errorf("Expected the resource ID as scrape parameter label in %s, got %q", format, id)
+		}
+	}
+
+	if content, _ := (&Writer{format: FormatJSON}).encode(fileTargets(nil)); string(content) != "[]\n" {
+		t.Errorf("Expected an empty list, got %q", content)
+	}
+}
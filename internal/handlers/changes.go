@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+)
+
+// ChangesHandler handles the /changes endpoint, listing the resources added,
+// removed or relabelled by refreshes since the time in the since parameter
+func ChangesHandler(inv *inventory.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		since, err := parseSince(r.URL.Query().Get("since"), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(inv.Changes(since)); err != nil {
+			log.Printf("Failed to encode changes: %v", err)
+			http.Error(w, "Failed to encode changes", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// parseSince accepts an RFC 3339 timestamp, Unix seconds or a duration before
+// now, e.g. "6h". An empty value selects the whole history.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if since, err := time.Parse(time.RFC3339, value); err == nil {
+		return since, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q, must be an RFC 3339 timestamp, Unix seconds or a duration", value)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"":                     {},
+		"2024-05-01T10:00:00Z": time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		"1714557600":           time.Unix(1714557600, 0),
+		"90m":                  now.Add(-90 * time.Minute),
+	}
+	for value, expected := range tests {
+		since, err := parseSince(value, now)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", value, err)
+			continue
+		}
+		if !since.Equal(expected) {
+			t.Errorf("Expected %q to parse as %v, got %v", value, expected, since)
+		}
+	}
+
+	for _, value := range []string{"yesterday", "-1h", "2024-05-01"} {
+		if _, err := parseSince(value, now); err == nil {
+			t.Errorf("Expected an error for %q, got nil", value)
+		}
+	}
+}
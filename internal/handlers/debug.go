@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/enrich"
+)
+
+// EnrichmentDebugHandler handles the /debug/enrichment endpoint, showing which
+// enrichment rules matched which resources during the most recent discovery request
+func EnrichmentDebugHandler(pipeline *enrich.Pipeline) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(pipeline.LastReport()); err != nil {
+			log.Printf("Failed to encode enrichment report: %v", err)
+			http.Error(w, "Failed to encode enrichment report", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -2,86 +2,89 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"regexp"
-	"strings"
-	"time"
+	"net/url"
 
-	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/cache"
-	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
 )
 
 const (
-	cacheKey = "confluent_resources"
+	// labelStylePrefix emits resource labels with the optional prefix
+	labelStylePrefix = "prefix"
+	// labelStyleMeta emits resource labels as __meta_confluent_<name> for relabeling
+	labelStyleMeta = "meta"
 )
 
-var (
-	// validPrefixPattern is used to validate the prefix parameter
-	validPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_]*$`)
-)
-
-// Target represents a target for Prometheus to scrape
-type Target struct {
-	Targets []string            `json:"targets"`
-	Labels  map[string]string   `json:"labels"`
-	Params  map[string][]string `json:"params"`
-}
-
 // DiscoveryHandler handles the /discovery endpoint
-func DiscoveryHandler(client *confluent.Client, cache *cache.Cache, cacheDuration time.Duration) http.HandlerFunc {
+func DiscoveryHandler(inv *inventory.Inventory, labelBuilder *labels.Builder, targetProfiles map[string]config.TargetProfile, batchLimits targets.BatchLimits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check if we have cached data first, before potentially making API calls
-		cachedData, found := cache.Get(cacheKey)
-		var resourcesNeedFetching = !found
-		
 		// Parse query parameters
-		targetsParam := r.URL.Query().Get("targets")
-		if targetsParam == "" {
-			http.Error(w, "Missing required 'targets' parameter", http.StatusBadRequest)
+		assignment, err := parseTargets(r.URL.Query(), targetProfiles)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if assignment.Empty() {
+			http.Error(w, "Missing required 'targets' parameter. Provide 'targets', 'targets.<type>' or 'profile'", http.StatusBadRequest)
 			return
 		}
-
-		// Split targets by comma
-		targetsList := strings.Split(targetsParam, ",")
 
 		// Get optional prefix
-		prefix := r.URL.Query().Get("prefix")
-		if prefix != "" && !validPrefixPattern.MatchString(prefix) {
-			http.Error(w, "Invalid 'prefix' parameter. Must contain only alphanumeric characters and underscores", http.StatusBadRequest)
+		prefix, err := parsePrefix(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		// Add trailing underscore to prefix if it's not empty
-		if prefix != "" && !strings.HasSuffix(prefix, "_") {
-			prefix = prefix + "_"
+		// Get optional label style
+		labelStyle, err := parseLabelStyle(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		// After validating parameters, fetch data if needed
-		var resources []confluent.Resource
+		// Get optional batching of resource IDs into shared target groups
+		batch := r.URL.Query().Get("batch") == "true"
+		if batch && labelStyle == labelStyleMeta {
+			http.Error(w, "The 'batch' parameter can't be combined with label_style=meta", http.StatusBadRequest)
+			return
+		}
 
-		if resourcesNeedFetching {
-			// Fetch data from Confluent API since parameters are valid
-			log.Println("Cache miss. Fetching data from Confluent API...")
-			
-			var err error
-			resources, err = client.GetAllResources()
-			if err != nil {
-				log.Printf("Failed to fetch resources: %v", err)
-				http.Error(w, "Failed to fetch resources from Confluent API", http.StatusInternalServerError)
-				return
-			}
+		// Get optional shard selection
+		shard, shards, err := parseShardParams(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-			// Cache the results
-			cache.Set(cacheKey, resources, cacheDuration)
-		} else {
-			// Use cached data
-			log.Println("Using cached data")
-			resources = cachedData.([]confluent.Resource)
+		// After validating parameters, fetch data if needed
+		resources, err := inv.Resources()
+		if err != nil {
+			log.Printf("Failed to fetch resources: %v", err)
+			http.Error(w, "Failed to fetch resources from Confluent API", http.StatusInternalServerError)
+			return
 		}
+		setSnapshotHeaders(w, inv)
+
+		// Keep only the resources assigned to the requested shard
+		resources = shardResources(resources, shard, shards)
 
 		// Format response for Prometheus
-		response := formatResponse(resources, targetsList, prefix)
+		var response []targets.Target
+		if batch {
+			// Split resource IDs into chunks within the export request limits
+			response = targets.FormatBatched(resources, assignment, prefix, labelBuilder, batchLimits)
+		} else if labelStyle == labelStyleMeta {
+			// Meta labels ignore the prefix, relabeling maps them instead
+			response = targets.FormatMeta(resources, assignment, labelBuilder)
+		} else {
+			response = targets.Format(resources, assignment, prefix, labelBuilder)
+		}
 
 		// Set content type and return JSON response
 		w.Header().Set("Content-Type", "application/json")
@@ -96,39 +99,19 @@ func DiscoveryHandler(client *confluent.Client, cache *cache.Cache, cacheDuratio
 	}
 }
 
-// formatResponse formats the response for Prometheus
-func formatResponse(resources []confluent.Resource, targets []string, prefix string) []Target {
-	var response []Target
-
-	for _, resource := range resources {
-		// Create a new target with the requested targets
-		target := Target{
-			Targets: targets,
-			Labels:  make(map[string]string),
-			Params:  make(map[string][]string),
-		}
-
-		// Add labels with optional prefix
-		for k, v := range resource.Labels {
-			target.Labels[prefix+k] = v
-		}
-
-		// Add resource ID to params based on resource type
-		switch resource.ResourceType {
-		case "kafka":
-			target.Params["resource.kafka.id"] = []string{resource.ID}
-		case "schema_registry":
-			target.Params["resource.schema_registry.id"] = []string{resource.ID}
-		case "ksql":
-			target.Params["resource.ksql.id"] = []string{resource.ID}
-		case "compute_pool":
-			target.Params["resource.compute_pool.id"] = []string{resource.ID}
-		case "connector":
-			target.Params["resource.connector.id"] = []string{resource.ID}
-		}
+// parsePrefix reads and validates the optional prefix query parameter
+func parsePrefix(query url.Values) (string, error) {
+	return targets.ParsePrefix(query.Get("prefix"))
+}
 
-		response = append(response, target)
+// parseLabelStyle reads and validates the optional label_style query parameter
+func parseLabelStyle(query url.Values) (string, error) {
+	switch labelStyle := query.Get("label_style"); labelStyle {
+	case "", labelStylePrefix:
+		return labelStylePrefix, nil
+	case labelStyleMeta:
+		return labelStyleMeta, nil
+	default:
+		return "", errors.New("Invalid 'label_style' parameter. Must be 'prefix' or 'meta'")
 	}
-
-	return response
 }
\ No newline at end of file
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/export"
+)
+
+// ExportHandler handles the /export endpoint, proxying the Confluent Cloud
+// Metrics API export endpoint so Prometheus doesn't need the API secret
+func ExportHandler(proxy *export.Proxy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := proxy.Export(r.Context(), r.URL.Query())
+		if err != nil {
+			log.Printf("Failed to export metrics: %v", err)
+
+			var upstreamErr *export.UpstreamError
+			if errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusTooManyRequests {
+				// Pass rate limiting through so it shows up on the scrape
+				http.Error(w, "Export endpoint rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			http.Error(w, "Failed to export metrics from Confluent API", http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", export.ContentType)
+
+		if _, err := w.Write(body); err != nil {
+			log.Printf("Failed to write exported metrics: %v", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/infometrics"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+)
+
+// InventoryMetricsHandler handles the /metrics/inventory endpoint, exposing the
+// cached resources as info metrics for group_left joins
+func InventoryMetricsHandler(inv *inventory.Inventory, labelBuilder *labels.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resources, err := inv.Resources()
+		if err != nil {
+			log.Printf("Failed to fetch resources: %v", err)
+			http.Error(w, "Failed to fetch resources from Confluent API", http.StatusInternalServerError)
+			return
+		}
+		setSnapshotHeaders(w, inv)
+
+		w.Header().Set("Content-Type", infometrics.ContentType)
+
+		if _, err := w.Write(infometrics.Render(resources, labelBuilder, inv.Status())); err != nil {
+			log.Printf("Failed to write inventory metrics: %v", err)
+		}
+	}
+}
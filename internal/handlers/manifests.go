@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/manifests"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// ManifestsHandler handles the /manifests endpoint, rendering the discovered
+// targets as Prometheus Operator ScrapeConfig and optionally Probe manifests
+func ManifestsHandler(inv *inventory.Inventory, generator *manifests.Generator, targetProfiles map[string]config.TargetProfile) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Targets default to the Metrics API host
+		assignment, err := parseTargets(r.URL.Query(), targetProfiles)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if assignment.Empty() {
+			assignment = targets.Assignment{Fallback: []string{manifests.DefaultTarget}}
+		}
+
+		prefix, err := parsePrefix(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		includeProbes := r.URL.Query().Get("probes") == "true"
+
+		resources, err := inv.Resources()
+		if err != nil {
+			log.Printf("Failed to fetch resources: %v", err)
+			http.Error(w, "Failed to fetch resources from Confluent API", http.StatusInternalServerError)
+			return
+		}
+		setSnapshotHeaders(w, inv)
+
+		var objects []interface{}
+		for _, scrapeConfig := range generator.ScrapeConfigs(resources, assignment, prefix) {
+			objects = append(objects, scrapeConfig)
+		}
+
+		if includeProbes {
+			probes, err := generator.Probes(resources, prefix)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, probe := range probes {
+				objects = append(objects, probe)
+			}
+		}
+
+		content, err := manifests.Render(objects...)
+		if err != nil {
+			log.Printf("Failed to render manifests: %v", err)
+			http.Error(w, "Failed to render manifests", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(content)
+
+		log.Printf("Returned %d manifests", len(objects))
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/otel"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// OTelHandler handles the /otel endpoint, rendering an OpenTelemetry Collector
+// prometheus receiver configuration for the discovered targets
+func OTelHandler(inv *inventory.Inventory, generator *otel.Generator, targetProfiles map[string]config.TargetProfile) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		// Targets default to the Metrics API host
+		assignment, err := parseTargets(query, targetProfiles)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		prefix, err := parsePrefix(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		semanticConventions := query.Get("semconv") == "true"
+
+		var cfg otel.Config
+		switch mode := query.Get("mode"); mode {
+		case "", otel.ModeStatic:
+			if assignment.Empty() {
+				assignment = targets.Assignment{Fallback: []string{otel.DefaultTarget}}
+			}
+
+			resources, err := inv.Resources()
+			if err != nil {
+				log.Printf("Failed to fetch resources: %v", err)
+				http.Error(w, "Failed to fetch resources from Confluent API", http.StatusInternalServerError)
+				return
+			}
+			setSnapshotHeaders(w, inv)
+
+			cfg = generator.Static(resources, assignment, prefix, semanticConventions)
+		case otel.ModeHTTPSD:
+			cfg, err = generator.HTTPSD(discoveryQuery(query, assignment.Empty()), semanticConventions)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "Invalid 'mode' parameter. Must be 'static' or 'http_sd'", http.StatusBadRequest)
+			return
+		}
+
+		content, err := otel.Render(cfg)
+		if err != nil {
+			log.Printf("Failed to render OpenTelemetry configuration: %v", err)
+			http.Error(w, "Failed to render OpenTelemetry configuration", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(content)
+	}
+}
+
+// discoveryQuery keeps the /discovery parameters of a query, defaulting the
+// targets to the Metrics API host
+func discoveryQuery(query url.Values, defaultTargets bool) url.Values {
+	discovery := url.Values{}
+	for key, values := range query {
+		if key != "mode" && key != "semconv" {
+			discovery[key] = values
+		}
+	}
+	if defaultTargets {
+		discovery.Set("targets", otel.DefaultTarget)
+	}
+	return discovery
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// ProbesHandler handles the /discovery/probes endpoint, returning one target per
+// Confluent endpoint for use with the blackbox exporter
+func ProbesHandler(inv *inventory.Inventory, labelBuilder *labels.Builder, probeModules map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get optional prefix
+		prefix, err := parsePrefix(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Get optional module overriding the configured modules
+		module := r.URL.Query().Get("module")
+
+		resources, err := inv.Resources()
+		if err != nil {
+			log.Printf("Failed to fetch resources: %v", err)
+			http.Error(w, "Failed to fetch resources from Confluent API", http.StatusInternalServerError)
+			return
+		}
+		setSnapshotHeaders(w, inv)
+
+		response := targets.FormatProbes(resources, prefix, module, probeModules, labelBuilder)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode response: %v", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Returned %d probe targets to Prometheus", len(response))
+	}
+}
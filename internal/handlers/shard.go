@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+// parseShardParams reads the optional shard and shards query parameters.
+// Both must be given together; shards of 0 means sharding is disabled.
+func parseShardParams(query url.Values) (shard, shards uint64, err error) {
+	shardParam := query.Get("shard")
+	shardsParam := query.Get("shards")
+
+	if shardParam == "" && shardsParam == "" {
+		return 0, 0, nil
+	}
+
+	if shardParam == "" || shardsParam == "" {
+		return 0, 0, fmt.Errorf("'shard' and 'shards' parameters must be used together")
+	}
+
+	shards, err = strconv.ParseUint(shardsParam, 10, 64)
+	if err != nil || shards == 0 {
+		return 0, 0, fmt.Errorf("invalid 'shards' parameter. Must be a positive integer")
+	}
+
+	shard, err = strconv.ParseUint(shardParam, 10, 64)
+	if err != nil || shard >= shards {
+		return 0, 0, fmt.Errorf("invalid 'shard' parameter. Must be an integer between 0 and %d", shards-1)
+	}
+
+	return shard, shards, nil
+}
+
+// shardResources returns the resources belonging to the given shard. Resources
+// are assigned by resource ID using the same hash as Prometheus' hashmod action.
+func shardResources(resources []confluent.Resource, shard, shards uint64) []confluent.Resource {
+	if shards <= 1 {
+		return resources
+	}
+
+	var selected []confluent.Resource
+	for _, resource := range resources {
+		if hashMod(resource.ID, shards) == shard {
+			selected = append(selected, resource)
+		}
+	}
+
+	return selected
+}
+
+// hashMod mirrors the hashmod relabel action of Prometheus
+func hashMod(value string, modulus uint64) uint64 {
+	sum := md5.Sum([]byte(value))
+	return binary.BigEndian.Uint64(sum[8:]) % modulus
+}
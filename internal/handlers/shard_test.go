@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestParseShardParams(t *testing.T) {
+	shard, shards, err := parseShardParams(url.Values{"shard": {"2"}, "shards": {"4"}})
+	if err != nil {
+		t.Fatalf("Failed to parse shard parameters: %v", err)
+	}
+	if shard != 2 || shards != 4 {
+		t.Errorf("Expected shard 2 of 4, got shard %d of %d", shard, shards)
+	}
+
+	// Sharding is disabled without parameters
+	_, shards, err = parseShardParams(url.Values{})
+	if err != nil || shards != 0 {
+		t.Errorf("Expected sharding to be disabled, got shards %d, err %v", shards, err)
+	}
+
+	invalid := []url.Values{
+		{"shard": {"1"}},
+		{"shards": {"4"}},
+		{"shard": {"4"}, "shards": {"4"}},
+		{"shard": {"0"}, "shards": {"0"}},
+		{"shard": {"-1"}, "shards": {"4"}},
+		{"shard": {"a"}, "shards": {"4"}},
+	}
+	for _, query := range invalid {
+		if _, _, err := parseShardParams(query); err == nil {
+			t.Errorf("Expected an error for %v, got nil", query)
+		}
+	}
+}
+
+func TestShardResources(t *testing.T) {
+	var resources []confluent.Resource
+	for i := 0; i < 100; i++ {
+		resources = append(resources, confluent.Resource{ID: fmt.Sprintf("lkc-%03d", i), ResourceType: "kafka"})
+	}
+
+	const shards = 4
+	seen := make(map[string]uint64)
+
+	for shard := uint64(0); shard < shards; shard++ {
+		selected := shardResources(resources, shard, shards)
+		if len(selected) == 0 {
+			t.Errorf("Expected shard %d to receive resources", shard)
+		}
+
+		for _, resource := range selected {
+			if previous, found := seen[resource.ID]; found {
+				t.Errorf("Resource %s assigned to shards %d and %d", resource.ID, previous, shard)
+			}
+			seen[resource.ID] = shard
+		}
+	}
+
+	if len(seen) != len(resources) {
+		t.Errorf("Expected all %d resources to be assigned, got %d", len(resources), len(seen))
+	}
+
+	// Assignment is stable across calls
+	for _, resource := range shardResources(resources, 1, shards) {
+		if seen[resource.ID] != 1 {
+			t.Errorf("Expected resource %s to stay on shard 1", resource.ID)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+)
+
+const (
+	// snapshotAgeHeader tells callers how old the served resources are, in seconds
+	snapshotAgeHeader = "X-Snapshot-Age"
+	// staleWarning is the RFC 7234 warning for responses served from an expired snapshot
+	staleWarning = `110 - "Response is Stale"`
+)
+
+// InventoryStatusHandler handles the /health/inventory endpoint, reporting the
+// age and state of the served resource snapshot
+func InventoryStatusHandler(inv *inventory.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(inv.Status()); err != nil {
+			log.Printf("Failed to encode inventory status: %v", err)
+			http.Error(w, "Failed to encode inventory status", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// AcceptShrinkHandler handles the /admin/accept-shrink endpoint, serving the
+// resource types rejected by the shrinkage guard
+func AcceptShrinkHandler(inv *inventory.Inventory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !inv.AcceptShrink() {
+			http.Error(w, "No shrink pending", http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Accepted"))
+	}
+}
+
+// setSnapshotHeaders adds the age of the served snapshot to the response
+// headers, with a warning when the snapshot has expired
+func setSnapshotHeaders(w http.ResponseWriter, inv *inventory.Inventory) {
+	w.Header().Set(snapshotAgeHeader, strconv.Itoa(int(inv.Age().Seconds())))
+	if inv.Stale() {
+		w.Header().Set("Warning", staleWarning)
+	}
+}
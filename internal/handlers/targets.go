@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// typeTargetsPrefix prefixes query parameters that set targets for one resource type
+	typeTargetsPrefix = "targets."
+)
+
+// parseTargets builds the target assignment from the optional profile and the
+// targets and targets.<type> query parameters. Query parameters take precedence
+// over the profile, and per-type targets take precedence over global ones.
+// The returned assignment is empty if none of the parameters are set.
+func parseTargets(query url.Values, profiles map[string]config.TargetProfile) (targets.Assignment, error) {
+	assignment := targets.Assignment{
+		ByType: make(map[string][]string),
+	}
+
+	if name := query.Get("profile"); name != "" {
+		profile, ok := profiles[name]
+		if !ok {
+			return assignment, fmt.Errorf("unknown target profile '%s'", name)
+		}
+
+		assignment = targets.FromProfile(profile)
+	}
+
+	if targetsParam := query.Get("targets"); targetsParam != "" {
+		assignment.Fallback = strings.Split(targetsParam, ",")
+	}
+
+	for key := range query {
+		if !strings.HasPrefix(key, typeTargetsPrefix) {
+			continue
+		}
+
+		resourceType := strings.TrimPrefix(key, typeTargetsPrefix)
+		if !targets.IsResourceType(resourceType) {
+			return assignment, fmt.Errorf("invalid '%s' parameter. Unknown resource type '%s', must be one of %s", key, resourceType, strings.Join(targets.ResourceTypes, ", "))
+		}
+
+		if value := query.Get(key); value != "" {
+			assignment.ByType[resourceType] = strings.Split(value, ",")
+		}
+	}
+
+	return assignment, nil
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+)
+
+func TestParseTargets(t *testing.T) {
+	profiles := map[string]config.TargetProfile{
+		"default": {
+			Targets: []string{"api.telemetry.confluent.cloud"},
+			ResourceTypes: map[string][]string{
+				"connector": {"connect-health:9100"},
+				"ksql":      {"ksql-exporter:9200"},
+			},
+		},
+	}
+
+	query := url.Values{
+		"profile":      {"default"},
+		"targets.ksql": {"ksql-a:9200,ksql-b:9200"},
+	}
+
+	assignment, err := parseTargets(query, profiles)
+	if err != nil {
+		t.Fatalf("Failed to parse targets: %v", err)
+	}
+
+	tests := map[string][]string{
+		"kafka":     {"api.telemetry.confluent.cloud"},
+		"connector": {"connect-health:9100"},
+		"ksql":      {"ksql-a:9200", "ksql-b:9200"},
+	}
+	for resourceType, expected := range tests {
+		if got := assignment.ForType(resourceType); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected targets %v for %s, got %v", expected, resourceType, got)
+		}
+	}
+
+	// No parameters yields an empty assignment
+	assignment, err = parseTargets(url.Values{}, profiles)
+	if err != nil || !assignment.Empty() {
+		t.Errorf("Expected an empty assignment without parameters, got %v, err %v", assignment, err)
+	}
+
+	invalid := []url.Values{
+		{"profile": {"missing"}},
+		{"targets.kafak": {"host:443"}},
+	}
+	for _, query := range invalid {
+		if _, err := parseTargets(query, profiles); err == nil {
+			t.Errorf("Expected an error for %v, got nil", query)
+		}
+	}
+}
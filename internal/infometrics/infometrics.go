@@ -0,0 +1,149 @@
+package infometrics
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+)
+
+const (
+	// ContentType is the OpenMetrics text format content type
+	ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	infoMetric         = "confluent_resource_info"
+	countMetric        = "confluent_resources"
+	snapshotAgeMetric  = "confluent_sd_snapshot_age_seconds"
+	staleMetric        = "confluent_sd_snapshot_stale"
+	staleSegmentMetric = "confluent_sd_segment_stale_since_timestamp_seconds"
+	shrinkMetric       = "confluent_sd_shrink_pending"
+)
+
+// Render writes the resources as OpenMetrics confluent_resource_info series
+// with a constant value of 1, followed by resource counts per type and
+// environment and the state of the served snapshot. Series are sorted so the
+// output is stable between scrapes.
+func Render(resources []confluent.Resource, labelBuilder *labels.Builder, status inventory.Status) []byte {
+	var buf bytes.Buffer
+
+	sorted := make([]confluent.Resource, len(resources))
+	copy(sorted, resources)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ResourceType != sorted[j].ResourceType {
+			return sorted[i].ResourceType < sorted[j].ResourceType
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	fmt.Fprintf(&buf, "# HELP %s Confluent Cloud resources with their discovered labels.\n", infoMetric)
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n", infoMetric)
+
+	type countKey struct {
+		resourceType    string
+		environmentName string
+	}
+	counts := make(map[countKey]int)
+
+	for _, resource := range sorted {
+		seriesLabels := labelBuilder.Build(resource)
+		// The identifying labels always win over resource labels of the same name
+		seriesLabels["resource_id"] = resource.ID
+		seriesLabels["resource_type"] = resource.ResourceType
+
+		writeSample(&buf, infoMetric, seriesLabels, 1)
+
+		counts[countKey{resource.ResourceType, resource.Labels["environment_name"]}]++
+	}
+
+	keys := make([]countKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].resourceType != keys[j].resourceType {
+			return keys[i].resourceType < keys[j].resourceType
+		}
+		return keys[i].environmentName < keys[j].environmentName
+	})
+
+	fmt.Fprintf(&buf, "# HELP %s Number of Confluent Cloud resources per type and environment.\n", countMetric)
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n", countMetric)
+
+	for _, key := range keys {
+		writeSample(&buf, countMetric, map[string]string{
+			"resource_type":    key.resourceType,
+			"environment_name": key.environmentName,
+		}, float64(counts[key]))
+	}
+
+	fmt.Fprintf(&buf, "# HELP %s Age of the served resource snapshot.\n", snapshotAgeMetric)
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n", snapshotAgeMetric)
+	writeSample(&buf, snapshotAgeMetric, nil, status.AgeSeconds)
+
+	stale := 0.0
+	if status.Stale {
+		stale = 1
+	}
+	fmt.Fprintf(&buf, "# HELP %s Whether a resource type of the served snapshot has outlived its cache TTL.\n", staleMetric)
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n", staleMetric)
+	writeSample(&buf, staleMetric, nil, stale)
+
+	fmt.Fprintf(&buf, "# HELP %s Fetch time of segments served from an earlier snapshot because they failed to refresh.\n", staleSegmentMetric)
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n", staleSegmentMetric)
+	for _, segment := range status.StaleSegments {
+		segmentLabels := map[string]string{
+			"environment_id": segment.EnvironmentID,
+			"resource_type":  segment.ResourceType,
+		}
+		if segment.ClusterID != "" {
+			segmentLabels["cluster_id"] = segment.ClusterID
+		}
+		writeSample(&buf, staleSegmentMetric, segmentLabels, float64(segment.Since.Unix()))
+	}
+
+	shrinkPending := 0.0
+	if status.PendingShrink != nil {
+		shrinkPending = 1
+	}
+	fmt.Fprintf(&buf, "# HELP %s Whether a refresh was rejected by the shrinkage guard and waits for acceptance.\n", shrinkMetric)
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n", shrinkMetric)
+	writeSample(&buf, shrinkMetric, nil, shrinkPending)
+
+	buf.WriteString("# EOF\n")
+
+	return buf.Bytes()
+}
+
+// writeSample writes a single sample with its labels in sorted order
+func writeSample(buf *bytes.Buffer, name string, sampleLabels map[string]string, value float64) {
+	names := make([]string, 0, len(sampleLabels))
+	for labelName := range sampleLabels {
+		names = append(names, labelName)
+	}
+	sort.Strings(names)
+
+	buf.WriteString(name)
+	if len(names) > 0 {
+		buf.WriteByte('{')
+		for i, labelName := range names {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%s=\"%s\"", labelName, escapeLabelValue(sampleLabels[labelName]))
+		}
+		buf.WriteByte('}')
+	}
+	fmt.Fprintf(buf, " %s\n", strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// labelValueEscaper escapes label values as required by the exposition format
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
@@ -0,0 +1,51 @@
+package infometrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+)
+
+func TestRender(t *testing.T) {
+	resources := []confluent.Resource{
+		{ID: "lkc-def456", ResourceType: "kafka", Labels: map[string]string{"environment_name": "prod", "cluster_name": "payments"}},
+		{ID: "lkc-abc123", ResourceType: "kafka", Labels: map[string]string{"environment_name": "prod", "cluster_name": "orders \"eu\""}},
+		{ID: "lsrc-abc123", ResourceType: "schema_registry", Labels: map[string]string{"environment_name": "dev"}},
+	}
+
+	expected := `# HELP confluent_resource_info Confluent Cloud resources with their discovered labels.
+# TYPE confluent_resource_info gauge
+confluent_resource_info{cluster_name="orders \"eu\"",environment_name="prod",resource_id="lkc-abc123",resource_type="kafka"} 1
+confluent_resource_info{cluster_name="payments",environment_name="prod",resource_id="lkc-def456",resource_type="kafka"} 1
+confluent_resource_info{environment_name="dev",resource_id="lsrc-abc123",resource_type="schema_registry"} 1
+# HELP confluent_resources Number of Confluent Cloud resources per type and environment.
+# TYPE confluent_resources gauge
+confluent_resources{environment_name="prod",resource_type="kafka"} 2
+confluent_resources{environment_name="dev",resource_type="schema_registry"} 1
+# HELP confluent_sd_snapshot_age_seconds Age of the served resource snapshot.
+# TYPE confluent_sd_snapshot_age_seconds gauge
+confluent_sd_snapshot_age_seconds 90.5
+# HELP confluent_sd_snapshot_stale Whether a resource type of the served snapshot has outlived its cache TTL.
+# TYPE confluent_sd_snapshot_stale gauge
+confluent_sd_snapshot_stale 1
+# HELP confluent_sd_segment_stale_since_timestamp_seconds Fetch time of segments served from an earlier snapshot because they failed to refresh.
+# TYPE confluent_sd_segment_stale_since_timestamp_seconds gauge
+confluent_sd_segment_stale_since_timestamp_seconds{environment_id="env-1",resource_type="kafka"} 1700000000
+# HELP confluent_sd_shrink_pending Whether a refresh was rejected by the shrinkage guard and waits for acceptance.
+# TYPE confluent_sd_shrink_pending gauge
+confluent_sd_shrink_pending 0
+# EOF
+`
+
+	if got := string(Render(resources, nil, inventory.Status{
+		AgeSeconds: 90.5,
+		Stale:      true,
+		StaleSegments: []inventory.StaleSegment{
+			{Segment: confluent.Segment{EnvironmentID: "env-1", ResourceType: "kafka"}, Since: time.Unix(1700000000, 0)},
+		},
+	})); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
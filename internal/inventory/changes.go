@@ -0,0 +1,132 @@
+package inventory
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// DefaultChangeHistory is the number of diffs kept when Options.ChangeHistory is unset
+	DefaultChangeHistory = 100
+)
+
+// Change is a resource added, removed or relabelled between two snapshots
+type Change struct {
+	ResourceType string            `json:"resource_type"`
+	ID           string            `json:"id"`
+	Environment  string            `json:"environment_id,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	// PreviousLabels are the labels before a label change
+	PreviousLabels map[string]string `json:"previous_labels,omitempty"`
+}
+
+// Diff describes how a refresh changed the served resources
+type Diff struct {
+	DetectedAt    time.Time `json:"detected_at"`
+	Added         []Change  `json:"added,omitempty"`
+	Removed       []Change  `json:"removed,omitempty"`
+	LabelsChanged []Change  `json:"labels_changed,omitempty"`
+}
+
+// Empty reports whether the diff has no changes
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.LabelsChanged) == 0
+}
+
+// Summary describes the number of changes for logging
+func (d Diff) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d labels changed", len(d.Added), len(d.Removed), len(d.LabelsChanged))
+}
+
+// diffResources compares two resource lists by resource type and ID, and
+// by cluster for connectors
+func diffResources(previous, current []confluent.Resource, detectedAt time.Time) Diff {
+	diff := Diff{DetectedAt: detectedAt}
+
+	before := make(map[string]confluent.Resource, len(previous))
+	for _, resource := range previous {
+		before[targets.ResourceKey(resource)] = resource
+	}
+
+	seen := make(map[string]bool, len(current))
+	for _, resource := range current {
+		key := targets.ResourceKey(resource)
+		seen[key] = true
+
+		old, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, newChange(resource))
+		case !maps.Equal(old.Labels, resource.Labels):
+			change := newChange(resource)
+			change.PreviousLabels = old.Labels
+			diff.LabelsChanged = append(diff.LabelsChanged, change)
+		}
+	}
+
+	for _, resource := range previous {
+		if !seen[targets.ResourceKey(resource)] {
+			diff.Removed = append(diff.Removed, newChange(resource))
+		}
+	}
+
+	sortChanges(diff.Added)
+	sortChanges(diff.Removed)
+	sortChanges(diff.LabelsChanged)
+	return diff
+}
+
+func newChange(resource confluent.Resource) Change {
+	return Change{
+		ResourceType: resource.ResourceType,
+		ID:           resource.ID,
+		Environment:  resource.Environment,
+		Labels:       resource.Labels,
+	}
+}
+
+// sortChanges orders changes by resource type, ID and cluster
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(a, b int) bool {
+		if changes[a].ResourceType != changes[b].ResourceType {
+			return changes[a].ResourceType < changes[b].ResourceType
+		}
+		if changes[a].ID != changes[b].ID {
+			return changes[a].ID < changes[b].ID
+		}
+		return changes[a].Labels["cluster_id"] < changes[b].Labels["cluster_id"]
+	})
+}
+
+// recordDiff adds the diff to the bounded history, called with the lock held
+func (i *Inventory) recordDiff(diff Diff) {
+	limit := i.options.ChangeHistory
+	if limit <= 0 {
+		limit = DefaultChangeHistory
+	}
+
+	i.changes = append(i.changes, diff)
+	if len(i.changes) > limit {
+		i.changes = append([]Diff(nil), i.changes[len(i.changes)-limit:]...)
+	}
+}
+
+// Changes returns the recorded diffs detected after since, oldest first. A
+// zero since returns the whole history.
+func (i *Inventory) Changes(since time.Time) []Diff {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	changes := []Diff{}
+	for _, diff := range i.changes {
+		if diff.DetectedAt.After(since) {
+			changes = append(changes, diff)
+		}
+	}
+	return changes
+}
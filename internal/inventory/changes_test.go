@@ -0,0 +1,99 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/enrich"
+)
+
+func TestDiffResources(t *testing.T) {
+	renamed := kafka("lkc-b")
+	renamed.Labels = map[string]string{"cluster_name": "orders"}
+	// The same ID with another type is a different resource
+	sameID := confluent.Resource{ID: "lkc-a", ResourceType: "connector"}
+
+	diff := diffResources(
+		[]confluent.Resource{kafka("lkc-a"), kafka("lkc-b"), kafka("lkc-c")},
+		[]confluent.Resource{kafka("lkc-a"), renamed, kafka("lkc-d"), sameID},
+		time.Now(),
+	)
+
+	if len(diff.Added) != 2 || diff.Added[0].ResourceType != "connector" || diff.Added[1].ID != "lkc-d" {
+		t.Errorf("Expected connector lkc-a and kafka lkc-d to be added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != "lkc-c" {
+		t.Errorf("Expected lkc-c to be removed, got %+v", diff.Removed)
+	}
+	if len(diff.LabelsChanged) != 1 {
+		t.Fatalf("Expected 1 label change, got %+v", diff.LabelsChanged)
+	}
+	change := diff.LabelsChanged[0]
+	if change.ID != "lkc-b" || change.Labels["cluster_name"] != "orders" || change.PreviousLabels["cluster_name"] != "lkc-b" {
+		t.Errorf("Unexpected label change: %+v", change)
+	}
+	if diff.Summary() != "2 added, 1 removed, 1 labels changed" {
+		t.Errorf("Unexpected summary %q", diff.Summary())
+	}
+
+	// Connector names are only unique within their cluster
+	connector := func(clusterID string) confluent.Resource {
+		return confluent.Resource{ID: "sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": clusterID}}
+	}
+	diff = diffResources([]confluent.Resource{connector("lkc-a")}, []confluent.Resource{connector("lkc-b")}, time.Now())
+	if len(diff.Added) != 1 || len(diff.Removed) != 1 || len(diff.LabelsChanged) != 0 {
+		t.Errorf("Expected the connector to move between clusters, got %+v", diff)
+	}
+
+	if diff := diffResources([]confluent.Resource{kafka("lkc-a")}, []confluent.Resource{kafka("lkc-a")}, time.Now()); !diff.Empty() {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
+
+func TestChangeHistory(t *testing.T) {
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a")}}
+	var notified []Diff
+	clock := newFakeClock()
+	inv := New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{
+		CacheDuration: time.Minute,
+		MaxStaleness:  time.Hour,
+		ChangeHistory: 2,
+		OnChange:      func(diff Diff) { notified = append(notified, diff) },
+	})
+
+	// The first snapshot and unchanged refreshes are not recorded
+	for n := 0; n < 2; n++ {
+		if err := inv.Refresh(); err != nil {
+			t.Fatalf("Failed to refresh: %v", err)
+		}
+	}
+	if changes := inv.Changes(time.Time{}); len(changes) != 0 {
+		t.Fatalf("Expected no changes, got %+v", changes)
+	}
+
+	for _, id := range []string{"lkc-b", "lkc-c", "lkc-d"} {
+		fetcher.set([]confluent.Resource{kafka(id)}, nil)
+		if err := inv.Refresh(); err != nil {
+			t.Fatalf("Failed to refresh: %v", err)
+		}
+		clock.Advance(time.Second)
+	}
+
+	// The history keeps the 2 latest diffs
+	changes := inv.Changes(time.Time{})
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 diffs, got %d", len(changes))
+	}
+	if changes[0].Removed[0].ID != "lkc-b" || changes[1].Added[0].ID != "lkc-d" {
+		t.Errorf("Expected the diffs removing lkc-b and adding lkc-d, got %+v", changes)
+	}
+
+	if len(notified) != 3 {
+		t.Errorf("Expected every diff to be passed to OnChange, got %d", len(notified))
+	}
+
+	if changes := inv.Changes(changes[0].DetectedAt); len(changes) != 1 || changes[0].Removed[0].ID != "lkc-c" {
+		t.Errorf("Expected only the latest diff, got %+v", changes)
+	}
+}
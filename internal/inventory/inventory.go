@@ -0,0 +1,836 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/cache"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/enrich"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// Fetcher loads resources from the Confluent API
+type Fetcher interface {
+	FetchResources() (*confluent.FetchResult, error)
+	FetchResourceTypes(resourceTypes []string, kafkaClusters []confluent.Resource) (*confluent.FetchResult, error)
+}
+
+// Options controls how long resources are cached and served
+type Options struct {
+	// CacheDuration is how long resources are served as fresh
+	CacheDuration time.Duration
+	// TypeTTLs override the cache duration of individual resource types,
+	// which are then also refreshed on their own schedule
+	TypeTTLs map[string]time.Duration
+	// JitterPercent adds up to this share of the TTL to every expiry, so
+	// entries cached together don't expire together
+	JitterPercent float64
+	// MaxStaleness bounds how long an expired snapshot is served while refreshes fail
+	MaxStaleness time.Duration
+	// MaxDropPercent rejects refreshed resource types losing more than this
+	// share of their resources, or all of them. 0 disables the guard.
+	MaxDropPercent float64
+	// SnapshotPath persists the served snapshot across restarts when set
+	SnapshotPath string
+	// ChangeHistory is the number of diffs between snapshots kept, DefaultChangeHistory when 0
+	ChangeHistory int
+	// OnChange is called with every diff between snapshots while the
+	// inventory is locked, so it must not block
+	OnChange func(Diff)
+}
+
+// Validate checks the cache and change history settings
+func (o Options) Validate() error {
+	for resourceType, ttl := range o.TypeTTLs {
+		if !targets.IsResourceType(resourceType) {
+			return fmt.Errorf("unknown resource type %q in cache TTLs", resourceType)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("cache TTL for %s must be positive, got %v", resourceType, ttl)
+		}
+	}
+	if o.ChangeHistory < 0 {
+		return fmt.Errorf("change history must not be negative, got %d", o.ChangeHistory)
+	}
+	if o.JitterPercent < 0 || o.JitterPercent > 100 {
+		return fmt.Errorf("cache jitter_percent must be between 0 and 100, got %v", o.JitterPercent)
+	}
+	return nil
+}
+
+// ErrShrinkRejected is returned by refreshes rejected by the shrinkage guard
+var ErrShrinkRejected = errors.New("refresh rejected by shrinkage guard")
+
+// Shrink describes refreshed resource types that lost too many resources to
+// replace the served ones. The counts cover the rejected types only.
+type Shrink struct {
+	DetectedAt    time.Time      `json:"detected_at"`
+	PreviousCount int            `json:"previous_count"`
+	NewCount      int            `json:"new_count"`
+	DropPercent   float64        `json:"drop_percent"`
+	Removed       map[string]int `json:"removed"` // Number of lost resources by type
+}
+
+// Snapshot is a successfully fetched copy of the resources
+type Snapshot struct {
+	Resources []confluent.Resource
+	// FetchedAt is the fetch time of the oldest resource type
+	FetchedAt time.Time
+	// TypeFetchedAt is the fetch time of each resource type
+	TypeFetchedAt map[string]time.Time
+	// StaleSegments failed to refresh and were copied from an earlier snapshot
+	StaleSegments []StaleSegment
+}
+
+// StaleSegment is a segment served from an earlier snapshot because it failed to refresh
+type StaleSegment struct {
+	confluent.Segment
+	// Since is when the served copy of the segment was fetched
+	Since time.Time `json:"since"`
+	Error string    `json:"error"`
+}
+
+// Status describes the snapshot currently served
+type Status struct {
+	FetchedAt  time.Time `json:"fetched_at"`
+	AgeSeconds float64   `json:"age_seconds"`
+	Resources  int       `json:"resources"`
+	Stale      bool      `json:"stale"`
+	Refreshing bool      `json:"refreshing"`
+	LastError  string    `json:"last_error,omitempty"`
+	// TypeAgeSeconds is the age of each resource type
+	TypeAgeSeconds map[string]float64 `json:"type_age_seconds,omitempty"`
+	// StaleSegments are served from an earlier snapshot after failing to refresh
+	StaleSegments []StaleSegment `json:"stale_segments,omitempty"`
+	// PendingShrink is a rejected refresh waiting for an operator to accept it
+	PendingShrink *Shrink `json:"pending_shrink,omitempty"`
+}
+
+// Inventory provides the enriched Confluent Cloud resources shared by all endpoints
+type Inventory struct {
+	client   Fetcher
+	cache    *cache.Cache[string, []confluent.Resource]
+	pipeline *enrich.Pipeline
+	options  Options
+
+	mu         sync.Mutex
+	snapshot   *Snapshot
+	generation uint64                  // Incremented whenever the snapshot is replaced
+	inflight   map[string]*refreshCall // Keyed by the resource types being fetched
+	lastError  error
+
+	// pending holds the resource types rejected by the shrinkage guard
+	pending map[string]*pendingType
+
+	// changes is the bounded history of diffs between served snapshots
+	changes []Diff
+
+	// persistMu serialises snapshot writes, persisted is the generation on disk
+	persistMu sync.Mutex
+	persisted uint64
+}
+
+// pendingType is a refreshed resource type rejected by the shrinkage guard
+type pendingType struct {
+	resources     []confluent.Resource
+	staleSegments []StaleSegment
+	fetchedAt     time.Time
+	shrink        *Shrink
+}
+
+// refreshCall is a refresh in progress, shared by every caller that asks for
+// a refresh before it completes
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// New creates an inventory backed by the Confluent client and cache. The
+// inventory reads the time from the cache clock.
+func New(client Fetcher, cache *cache.Cache[string, []confluent.Resource], pipeline *enrich.Pipeline, options Options) *Inventory {
+	return &Inventory{
+		client:   client,
+		cache:    cache,
+		pipeline: pipeline,
+		options:  options,
+		inflight: make(map[string]*refreshCall),
+		pending:  make(map[string]*pendingType),
+	}
+}
+
+// Resources returns the enriched resources. Every resource type is cached
+// separately. Once a snapshot exists, expired types are served from it
+// immediately while they are refreshed in the background, until they are
+// older than the maximum staleness, after which callers wait for the
+// Confluent API again.
+func (i *Inventory) Resources() ([]confluent.Resource, error) {
+	snapshot := i.Snapshot()
+	if snapshot == nil {
+		// Fetch data from Confluent API
+		log.Println("Cache miss. Fetching data from Confluent API...")
+
+		if err := i.Refresh(); err != nil {
+			return nil, err
+		}
+		return i.pipeline.Run(i.Snapshot().Resources), nil
+	}
+
+	cached := make(map[string][]confluent.Resource, len(confluent.ResourceTypes))
+	var expired, overdue []string
+	var cachedAge time.Duration
+
+	for _, resourceType := range confluent.ResourceTypes {
+		if cachedData, age, found := i.cache.GetWithAge(resourceType); found {
+			cached[resourceType] = cachedData
+			cachedAge = max(cachedAge, age)
+			continue
+		}
+
+		age := i.since(snapshot.typeFetchedAt(resourceType))
+		if age > i.options.MaxStaleness {
+			overdue = append(overdue, resourceType)
+			continue
+		}
+
+		// Serve the last good snapshot while it's renewed
+		log.Printf("Cache expired for %s. Serving snapshot from %v ago while refreshing", resourceType, age.Round(time.Second))
+		expired = append(expired, resourceType)
+	}
+
+	if len(overdue) > 0 {
+		log.Printf("Cache expired for %s beyond max staleness. Fetching data from Confluent API...", strings.Join(overdue, ", "))
+		err := i.refreshTypes(overdue)
+		if errors.Is(err, ErrShrinkRejected) {
+			// Rejected types keep being served until the change is accepted
+			log.Printf("Warning: serving %s resources beyond max staleness while a shrink is pending", strings.Join(overdue, ", "))
+		} else if err != nil {
+			return nil, fmt.Errorf("%s resources exceeded max staleness of %v and refresh failed: %w", strings.Join(overdue, ", "), i.options.MaxStaleness, err)
+		}
+		snapshot = i.Snapshot()
+	}
+	if len(expired) > 0 {
+		i.refreshInBackground(expired)
+	}
+	if len(overdue) == 0 && len(expired) == 0 {
+		log.Printf("Using cached data from %v ago", cachedAge.Round(time.Second))
+	}
+
+	var resources []confluent.Resource
+	for _, resourceType := range confluent.ResourceTypes {
+		if cachedData, found := cached[resourceType]; found {
+			resources = append(resources, cachedData...)
+		} else {
+			resources = append(resources, snapshot.resourcesOfType(resourceType)...)
+		}
+	}
+
+	// Enrich resources with derived labels
+	return i.pipeline.Run(resources), nil
+}
+
+// Refresh fetches every resource type from the Confluent API and replaces
+// the cached copy. Concurrent calls share a single fetch and its result.
+func (i *Inventory) Refresh() error {
+	return i.refreshTypes(confluent.ResourceTypes)
+}
+
+// RefreshType fetches the resources of a single type from the Confluent API,
+// keeping the other types. It joins a running refresh of the type, including
+// a full refresh, instead of fetching again.
+func (i *Inventory) RefreshType(resourceType string) error {
+	return i.refreshTypes([]string{resourceType})
+}
+
+// refreshTypes fetches the resource types together in a single fetch. Types
+// already being fetched join the running refresh instead, so a type is never
+// fetched twice at once.
+func (i *Inventory) refreshTypes(types []string) error {
+	i.mu.Lock()
+	var joined []*refreshCall
+	var missing []string
+	for _, resourceType := range types {
+		if call := i.inflight[resourceType]; call != nil {
+			if !slices.Contains(joined, call) {
+				joined = append(joined, call)
+			}
+			continue
+		}
+		missing = append(missing, resourceType)
+	}
+
+	var call *refreshCall
+	if len(missing) > 0 {
+		call = &refreshCall{done: make(chan struct{})}
+		for _, resourceType := range missing {
+			i.inflight[resourceType] = call
+		}
+	}
+	i.mu.Unlock()
+
+	var err error
+	if call != nil {
+		// Rejected refreshes may still have replaced the types that passed the guard
+		call.err = i.fetch(missing)
+		i.persist()
+
+		i.mu.Lock()
+		for _, resourceType := range missing {
+			delete(i.inflight, resourceType)
+		}
+		i.mu.Unlock()
+		close(call.done)
+
+		err = call.err
+	}
+
+	for _, other := range joined {
+		<-other.done
+		if err == nil {
+			err = other.err
+		}
+	}
+	return err
+}
+
+// persist saves the served snapshot, logging failures since the snapshot
+// on disk is only a startup optimisation
+func (i *Inventory) persist() {
+	// Concurrent refreshes write one at a time, each reading the snapshot
+	// once it may write, so an older snapshot never replaces a newer one
+	i.persistMu.Lock()
+	defer i.persistMu.Unlock()
+
+	i.mu.Lock()
+	snapshot, generation := i.snapshot, i.generation
+	i.mu.Unlock()
+
+	if generation == i.persisted {
+		return
+	}
+	if err := i.saveSnapshot(snapshot); err != nil {
+		log.Printf("Warning: failed to persist snapshot: %v", err)
+		return
+	}
+	i.persisted = generation
+}
+
+// fetch loads the resource types from the Confluent API and stores the snapshot
+func (i *Inventory) fetch(types []string) error {
+	var result *confluent.FetchResult
+	var err error
+	if len(types) == len(confluent.ResourceTypes) {
+		log.Println("Refreshing resources from Confluent API...")
+		result, err = i.client.FetchResources()
+	} else {
+		log.Printf("Refreshing %s resources from Confluent API...", strings.Join(types, ", "))
+		// Connectors are listed from the known Kafka clusters unless those are refreshed too
+		result, err = i.client.FetchResourceTypes(types, i.Snapshot().resourcesOfType("kafka"))
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.lastError = err
+	if err != nil {
+		return err
+	}
+
+	now := i.now()
+	resources, staleSegments := mergeFailedSegments(result, i.snapshot)
+	if len(result.Failed) > 0 {
+		log.Printf("Warning: %d segments failed to refresh, serving them from the previous snapshot", len(result.Failed))
+	}
+
+	// Types that lost too many resources are held back until accepted, the
+	// others replace the served ones
+	candidate := withTypes(i.snapshot, types, resources, staleSegments, now)
+	shrinks := i.checkShrink(candidate, types)
+
+	var accepted []string
+	for _, resourceType := range types {
+		shrink := shrinks[resourceType]
+		if shrink == nil {
+			delete(i.pending, resourceType)
+			accepted = append(accepted, resourceType)
+			continue
+		}
+
+		log.Printf("Warning: refresh lost %.1f%% of %s resources (%d -> %d), keeping the previous ones until the change is accepted",
+			shrink.DropPercent, resourceType, shrink.PreviousCount, shrink.NewCount)
+		if previous := i.pending[resourceType]; previous != nil {
+			shrink.DetectedAt = previous.shrink.DetectedAt
+		}
+		// Cache the served resources again, so the rejected type is retried
+		// on its normal schedule instead of on every request
+		i.cache.Set(resourceType, i.snapshot.resourcesOfType(resourceType), i.expiry(resourceType))
+		i.pending[resourceType] = &pendingType{
+			resources:     candidate.resourcesOfType(resourceType),
+			staleSegments: candidate.staleSegmentsOfType(resourceType),
+			fetchedAt:     now,
+			shrink:        shrink,
+		}
+	}
+
+	if len(accepted) > 0 {
+		i.replace(withTypes(i.snapshot, accepted, resources, staleSegments, now), accepted)
+	}
+	if len(shrinks) > 0 {
+		i.lastError = ErrShrinkRejected
+		return ErrShrinkRejected
+	}
+	return nil
+}
+
+// withTypes returns a copy of the base snapshot with the resources and stale
+// segments of the given types replaced. Resources and segments of other types
+// are ignored.
+func withTypes(base *Snapshot, types []string, resources []confluent.Resource, staleSegments []StaleSegment, fetchedAt time.Time) *Snapshot {
+	replaced := make(map[string]bool, len(types))
+	for _, resourceType := range types {
+		replaced[resourceType] = true
+	}
+
+	snapshot := &Snapshot{TypeFetchedAt: make(map[string]time.Time)}
+	for _, resource := range resources {
+		if replaced[resource.ResourceType] {
+			snapshot.Resources = append(snapshot.Resources, resource)
+		}
+	}
+	for _, segment := range staleSegments {
+		if replaced[segment.ResourceType] {
+			snapshot.StaleSegments = append(snapshot.StaleSegments, segment)
+		}
+	}
+
+	if base != nil {
+		for _, resource := range base.Resources {
+			if !replaced[resource.ResourceType] {
+				snapshot.Resources = append(snapshot.Resources, resource)
+			}
+		}
+		for _, segment := range base.StaleSegments {
+			if !replaced[segment.ResourceType] {
+				snapshot.StaleSegments = append(snapshot.StaleSegments, segment)
+			}
+		}
+		for resourceType, typeFetchedAt := range base.TypeFetchedAt {
+			snapshot.TypeFetchedAt[resourceType] = typeFetchedAt
+		}
+	}
+
+	for _, resourceType := range types {
+		snapshot.TypeFetchedAt[resourceType] = fetchedAt
+	}
+
+	// Group resources by type so partial refreshes keep a stable order
+	sort.SliceStable(snapshot.Resources, func(a, b int) bool {
+		return typeIndex(snapshot.Resources[a].ResourceType) < typeIndex(snapshot.Resources[b].ResourceType)
+	})
+	sortStaleSegments(snapshot.StaleSegments)
+
+	snapshot.FetchedAt = fetchedAt
+	for _, typeFetchedAt := range snapshot.TypeFetchedAt {
+		if typeFetchedAt.Before(snapshot.FetchedAt) {
+			snapshot.FetchedAt = typeFetchedAt
+		}
+	}
+
+	return snapshot
+}
+
+// replace serves the snapshot and caches the resources of the refreshed types
+func (i *Inventory) replace(snapshot *Snapshot, types []string) {
+	if i.snapshot != nil {
+		if diff := diffResources(i.snapshot.Resources, snapshot.Resources, i.now()); !diff.Empty() {
+			log.Printf("Inventory changed: %s", diff.Summary())
+			i.recordDiff(diff)
+			if i.options.OnChange != nil {
+				i.options.OnChange(diff)
+			}
+		}
+	}
+
+	i.snapshot = snapshot
+	i.generation++
+	for _, resourceType := range types {
+		i.cache.Set(resourceType, snapshot.resourcesOfType(resourceType), i.expiry(resourceType))
+	}
+}
+
+// now returns the time of the cache clock, so snapshot ages and cache
+// expiry agree
+func (i *Inventory) now() time.Time {
+	return i.cache.Now()
+}
+
+// since returns the time elapsed since t on the cache clock
+func (i *Inventory) since(t time.Time) time.Duration {
+	return i.now().Sub(t)
+}
+
+// ttl returns the cache duration of a resource type
+func (i *Inventory) ttl(resourceType string) time.Duration {
+	if ttl, ok := i.options.TypeTTLs[resourceType]; ok && ttl > 0 {
+		return ttl
+	}
+	return i.options.CacheDuration
+}
+
+// expiry returns the TTL of a resource type plus random jitter
+func (i *Inventory) expiry(resourceType string) time.Duration {
+	ttl := i.ttl(resourceType)
+	jitter := time.Duration(float64(ttl) * i.options.JitterPercent / 100)
+	if jitter <= 0 {
+		return ttl
+	}
+	return ttl + rand.N(jitter)
+}
+
+// checkShrink compares every refreshed type of the snapshot with the served
+// one and describes the loss of the types the shrinkage guard rejects
+func (i *Inventory) checkShrink(snapshot *Snapshot, types []string) map[string]*Shrink {
+	if i.options.MaxDropPercent <= 0 || i.snapshot == nil {
+		return nil
+	}
+
+	shrinks := make(map[string]*Shrink)
+	for _, resourceType := range types {
+		previous, current := i.snapshot.resourcesOfType(resourceType), snapshot.resourcesOfType(resourceType)
+		if len(previous) == 0 {
+			continue
+		}
+
+		dropPercent := float64(len(previous)-len(current)) / float64(len(previous)) * 100
+		if len(current) > 0 && dropPercent <= i.options.MaxDropPercent {
+			continue
+		}
+
+		kept := make(map[string]bool, len(current))
+		for _, resource := range current {
+			kept[targets.ResourceKey(resource)] = true
+		}
+		removed := 0
+		for _, resource := range previous {
+			if !kept[targets.ResourceKey(resource)] {
+				removed++
+			}
+		}
+
+		shrinks[resourceType] = &Shrink{
+			DetectedAt:    i.now(),
+			PreviousCount: len(previous),
+			NewCount:      len(current),
+			DropPercent:   dropPercent,
+			Removed:       map[string]int{resourceType: removed},
+		}
+	}
+	return shrinks
+}
+
+// AcceptShrink serves the resource types rejected by the shrinkage guard,
+// on top of the current snapshot so types refreshed since are kept. It
+// returns false if no refresh is pending.
+func (i *Inventory) AcceptShrink() bool {
+	i.mu.Lock()
+	if len(i.pending) == 0 {
+		i.mu.Unlock()
+		return false
+	}
+
+	types := make([]string, 0, len(i.pending))
+	for resourceType := range i.pending {
+		types = append(types, resourceType)
+	}
+	sort.Slice(types, func(a, b int) bool {
+		return typeIndex(types[a]) < typeIndex(types[b])
+	})
+
+	snapshot := i.snapshot
+	for _, resourceType := range types {
+		pending := i.pending[resourceType]
+		log.Printf("Accepted shrink of %s from %d to %d resources", resourceType, pending.shrink.PreviousCount, pending.shrink.NewCount)
+		snapshot = withTypes(snapshot, []string{resourceType}, pending.resources, pending.staleSegments, pending.fetchedAt)
+	}
+
+	i.replace(snapshot, types)
+	i.pending = make(map[string]*pendingType)
+	i.lastError = nil
+	i.mu.Unlock()
+
+	i.persist()
+	return true
+}
+
+// pendingShrink combines the losses of the pending resource types, called
+// with the lock held
+func (i *Inventory) pendingShrink() *Shrink {
+	if len(i.pending) == 0 {
+		return nil
+	}
+
+	shrink := &Shrink{Removed: make(map[string]int)}
+	for resourceType, pending := range i.pending {
+		if shrink.DetectedAt.IsZero() || pending.shrink.DetectedAt.Before(shrink.DetectedAt) {
+			shrink.DetectedAt = pending.shrink.DetectedAt
+		}
+		shrink.PreviousCount += pending.shrink.PreviousCount
+		shrink.NewCount += pending.shrink.NewCount
+		shrink.Removed[resourceType] = pending.shrink.Removed[resourceType]
+	}
+	shrink.DropPercent = float64(shrink.PreviousCount-shrink.NewCount) / float64(shrink.PreviousCount) * 100
+	return shrink
+}
+
+// mergeFailedSegments adds the resources of failed segments from the previous
+// snapshot to the fetched resources and describes the segments as stale
+func mergeFailedSegments(result *confluent.FetchResult, previous *Snapshot) ([]confluent.Resource, []StaleSegment) {
+	resources := append([]confluent.Resource(nil), result.Resources...)
+	if len(result.Failed) == 0 {
+		return resources, nil
+	}
+
+	var staleSegments []StaleSegment
+	for segment, err := range result.Failed {
+		staleSegment := StaleSegment{Segment: segment, Error: err.Error()}
+		if previous != nil {
+			staleSegment.Since = previous.typeFetchedAt(segment.ResourceType)
+			// Segments that were already stale keep their original fetch time
+			for _, previousSegment := range previous.StaleSegments {
+				if previousSegment.Segment == segment {
+					staleSegment.Since = previousSegment.Since
+				}
+			}
+		}
+		staleSegments = append(staleSegments, staleSegment)
+	}
+
+	if previous == nil {
+		return resources, staleSegments
+	}
+
+	// Resources that were fetched take precedence
+	fetched := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		fetched[targets.ResourceKey(resource)] = true
+	}
+
+	for _, resource := range previous.Resources {
+		if inFailedSegment(result.Failed, resource) && !fetched[targets.ResourceKey(resource)] {
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, staleSegments
+}
+
+// inFailedSegment reports whether the resource belongs to a failed segment.
+// Connectors belong to the segment of their cluster and of their environment.
+func inFailedSegment(failed map[confluent.Segment]error, resource confluent.Resource) bool {
+	segment := confluent.Segment{EnvironmentID: resource.Environment, ResourceType: resource.ResourceType}
+	if _, ok := failed[segment]; ok {
+		return true
+	}
+	if resource.ResourceType != "connector" {
+		return false
+	}
+
+	segment.ClusterID = resource.Labels["cluster_id"]
+	_, ok := failed[segment]
+	return ok
+}
+
+// Run refreshes every resource type immediately and then on every interval
+// until the context is done, so the cache is renewed before it expires. Types
+// with their own TTL are additionally refreshed on their own schedule, at
+// half their TTL.
+func (i *Inventory) Run(ctx context.Context, interval time.Duration) {
+	for resourceType, ttl := range i.options.TypeTTLs {
+		if ttl > 0 {
+			go i.runType(ctx, resourceType, ttl/2)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.Refresh(); err != nil {
+			log.Printf("Background refresh failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runType refreshes a single resource type on every interval until the context is done
+func (i *Inventory) runType(ctx context.Context, resourceType string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.RefreshType(resourceType); err != nil {
+				log.Printf("Background refresh of %s failed: %v", resourceType, err)
+			}
+		}
+	}
+}
+
+// Snapshot returns the last successfully fetched resources, or nil before the first fetch
+func (i *Inventory) Snapshot() *Snapshot {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.snapshot
+}
+
+// Stale reports whether any resource type of the served snapshot has outlived its TTL
+func (i *Inventory) Stale() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.stale()
+}
+
+// stale reports whether any resource type has outlived its TTL, called with the lock held
+func (i *Inventory) stale() bool {
+	if i.snapshot == nil {
+		return false
+	}
+	for _, resourceType := range confluent.ResourceTypes {
+		if i.since(i.snapshot.typeFetchedAt(resourceType)) > i.ttl(resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Age returns the time since the served snapshot was fetched
+func (i *Inventory) Age() time.Duration {
+	snapshot := i.Snapshot()
+	if snapshot == nil {
+		return 0
+	}
+	return i.since(snapshot.FetchedAt)
+}
+
+// Status reports the state of the served snapshot
+func (i *Inventory) Status() Status {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	status := Status{Refreshing: len(i.inflight) > 0}
+	if i.snapshot != nil {
+		status.FetchedAt = i.snapshot.FetchedAt
+		status.AgeSeconds = i.since(i.snapshot.FetchedAt).Seconds()
+		status.Resources = len(i.snapshot.Resources)
+		status.Stale = i.stale()
+		status.StaleSegments = i.snapshot.StaleSegments
+		status.TypeAgeSeconds = make(map[string]float64, len(confluent.ResourceTypes))
+		for _, resourceType := range confluent.ResourceTypes {
+			status.TypeAgeSeconds[resourceType] = i.since(i.snapshot.typeFetchedAt(resourceType)).Seconds()
+		}
+	}
+	status.PendingShrink = i.pendingShrink()
+	if i.lastError != nil {
+		status.LastError = i.lastError.Error()
+	}
+	return status
+}
+
+// refreshInBackground starts a single refresh of the resource types that
+// aren't already being fetched
+func (i *Inventory) refreshInBackground(types []string) {
+	i.mu.Lock()
+	var missing []string
+	for _, resourceType := range types {
+		if i.inflight[resourceType] == nil {
+			missing = append(missing, resourceType)
+		}
+	}
+	i.mu.Unlock()
+
+	if len(missing) == 0 {
+		return
+	}
+
+	go func() {
+		if err := i.refreshTypes(missing); err != nil {
+			log.Printf("Background refresh of %s failed: %v", strings.Join(missing, ", "), err)
+		}
+	}()
+}
+
+// typeFetchedAt returns the fetch time of a resource type, falling back to
+// the snapshot fetch time for types without their own
+func (s *Snapshot) typeFetchedAt(resourceType string) time.Time {
+	if fetchedAt, ok := s.TypeFetchedAt[resourceType]; ok {
+		return fetchedAt
+	}
+	return s.FetchedAt
+}
+
+// resourcesOfType returns the resources of a single type, nil-safe
+func (s *Snapshot) resourcesOfType(resourceType string) []confluent.Resource {
+	if s == nil {
+		return nil
+	}
+
+	var resources []confluent.Resource
+	for _, resource := range s.Resources {
+		if resource.ResourceType == resourceType {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// staleSegmentsOfType returns the stale segments of a single type
+func (s *Snapshot) staleSegmentsOfType(resourceType string) []StaleSegment {
+	var staleSegments []StaleSegment
+	for _, segment := range s.StaleSegments {
+		if segment.ResourceType == resourceType {
+			staleSegments = append(staleSegments, segment)
+		}
+	}
+	return staleSegments
+}
+
+// typeIndex orders resource types as they are fetched
+func typeIndex(resourceType string) int {
+	for index, known := range confluent.ResourceTypes {
+		if known == resourceType {
+			return index
+		}
+	}
+	return len(confluent.ResourceTypes)
+}
+
+// sortStaleSegments orders stale segments by environment, resource type and cluster
+func sortStaleSegments(staleSegments []StaleSegment) {
+	sort.Slice(staleSegments, func(a, b int) bool {
+		if staleSegments[a].EnvironmentID != staleSegments[b].EnvironmentID {
+			return staleSegments[a].EnvironmentID < staleSegments[b].EnvironmentID
+		}
+		if staleSegments[a].ResourceType != staleSegments[b].ResourceType {
+			return staleSegments[a].ResourceType < staleSegments[b].ResourceType
+		}
+		return staleSegments[a].ClusterID < staleSegments[b].ClusterID
+	})
+}
@@ -0,0 +1,710 @@
+package inventory
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/cache"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/enrich"
+)
+
+// fakeFetcher returns the configured resources and counts its calls
+type fakeFetcher struct {
+	mu        sync.Mutex
+	resources []confluent.Resource
+	err       error
+	failed    map[confluent.Segment]error
+	calls     int
+	// typeCalls counts the resource type fetches by resource type, and
+	// typeFetches the fetches themselves
+	typeCalls   map[string]int
+	typeFetches int
+	// clusters are the Kafka clusters passed to the last resource type fetch
+	clusters []confluent.Resource
+	// release blocks fetches until closed when set
+	release chan struct{}
+}
+
+func (f *fakeFetcher) FetchResources() (*confluent.FetchResult, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	f.wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &confluent.FetchResult{Resources: f.resources, Failed: f.failed}, nil
+}
+
+func (f *fakeFetcher) FetchResourceTypes(resourceTypes []string, kafkaClusters []confluent.Resource) (*confluent.FetchResult, error) {
+	f.mu.Lock()
+	if f.typeCalls == nil {
+		f.typeCalls = make(map[string]int)
+	}
+	for _, resourceType := range resourceTypes {
+		f.typeCalls[resourceType]++
+	}
+	f.typeFetches++
+	f.clusters = kafkaClusters
+	f.mu.Unlock()
+	f.wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	result := &confluent.FetchResult{Failed: make(map[confluent.Segment]error)}
+	for _, resource := range f.resources {
+		if slices.Contains(resourceTypes, resource.ResourceType) {
+			result.Resources = append(result.Resources, resource)
+		}
+	}
+	for segment, err := range f.failed {
+		if slices.Contains(resourceTypes, segment.ResourceType) {
+			result.Failed[segment] = err
+		}
+	}
+	return result, nil
+}
+
+// wait blocks until the release channel is closed, if set
+func (f *fakeFetcher) wait() {
+	f.mu.Lock()
+	release := f.release
+	f.mu.Unlock()
+
+	if release != nil {
+		<-release
+	}
+}
+
+func (f *fakeFetcher) typeCallCount(resourceType string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.typeCalls[resourceType]
+}
+
+// fetchCount returns the number of upstream fetches of any kind
+func (f *fakeFetcher) fetchCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls + f.typeFetches
+}
+
+func (f *fakeFetcher) set(resources []confluent.Resource, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.resources, f.err = resources, err
+}
+
+func (f *fakeFetcher) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
+
+// fakeClock is a manually advanced clock shared by a test cache and its inventory
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// eventually polls the condition until it holds or a second has passed,
+// for results of background refreshes
+func eventually(condition func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+// newTestCache creates a cache reading the fake clock, stopped at the end of the test
+func newTestCache(t *testing.T, clock *fakeClock) *cache.Cache[string, []confluent.Resource] {
+	c := cache.NewWithClock[string, []confluent.Resource](clock.Now)
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func kafka(id string) confluent.Resource {
+	return confluent.Resource{ID: id, ResourceType: "kafka", Environment: "env-1", Labels: map[string]string{"cluster_name": id}}
+}
+
+func TestResourcesServesStaleSnapshotWhileRefreshing(t *testing.T) {
+	clock := newFakeClock()
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a")}}
+	inv := New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour})
+
+	resources, err := inv.Resources()
+	if err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	if len(resources) != 1 || fetcher.callCount() != 1 {
+		t.Fatalf("Expected 1 resource from 1 fetch, got %d resources from %d fetches", len(resources), fetcher.callCount())
+	}
+
+	// Let the cache expire, the next call serves the old snapshot immediately
+	clock.Advance(2 * time.Minute)
+	fetcher.set([]confluent.Resource{kafka("lkc-a"), kafka("lkc-b")}, nil)
+
+	resources, err = inv.Resources()
+	if err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	if len(resources) != 1 {
+		t.Errorf("Expected the stale snapshot with 1 resource, got %d", len(resources))
+	}
+
+	// The background refresh replaces the snapshot
+	if !eventually(func() bool { return len(inv.Snapshot().Resources) == 2 }) {
+		t.Errorf("Expected the background refresh to replace the snapshot")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a")}}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	fetcher.set(nil, errors.New("unavailable"))
+	if err := inv.Refresh(); err == nil {
+		t.Fatal("Expected refresh error, got nil")
+	}
+
+	status := inv.Status()
+	if status.Resources != 1 {
+		t.Errorf("Expected the last good snapshot with 1 resource, got %d", status.Resources)
+	}
+	if status.LastError != "unavailable" {
+		t.Errorf("Expected last error 'unavailable', got %q", status.LastError)
+	}
+	if status.FetchedAt.IsZero() {
+		t.Errorf("Expected fetch time to be set")
+	}
+}
+
+func TestResourcesMaxStaleness(t *testing.T) {
+	clock := newFakeClock()
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a")}}
+	inv := New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: 10 * time.Minute})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Within the max staleness the expired snapshot survives failing refreshes
+	fetcher.set(nil, errors.New("unavailable"))
+	clock.Advance(2 * time.Minute)
+
+	resources, err := inv.Resources()
+	if err != nil {
+		t.Fatalf("Expected the stale snapshot, got error: %v", err)
+	}
+	if len(resources) != 1 {
+		t.Errorf("Expected 1 stale resource, got %d", len(resources))
+	}
+	if !inv.Stale() {
+		t.Errorf("Expected the snapshot to be reported as stale")
+	}
+
+	// Beyond it the refresh error is returned
+	clock.Advance(10 * time.Minute)
+
+	if _, err := inv.Resources(); err == nil {
+		t.Errorf("Expected error once the snapshot exceeded the max staleness, got nil")
+	}
+}
+
+func TestRefreshCoalescesConcurrentCalls(t *testing.T) {
+	release := make(chan struct{})
+	fetcher := &fakeFetcher{err: errors.New("throttled"), release: release}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour})
+
+	const callers = 10
+	errs := make(chan error, callers)
+	for n := 0; n < callers; n++ {
+		go func() {
+			_, err := inv.Resources()
+			errs <- err
+		}()
+	}
+
+	// Wait until the first fetch is in flight, and give the other callers
+	// time to join it, before letting it finish
+	eventually(func() bool { return fetcher.callCount() > 0 })
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for n := 0; n < callers; n++ {
+		if err := <-errs; err == nil || err.Error() != "throttled" {
+			t.Errorf("Expected the shared fetch error, got %v", err)
+		}
+	}
+
+	if calls := fetcher.callCount(); calls != 1 {
+		t.Errorf("Expected 1 upstream fetch, got %d", calls)
+	}
+}
+
+func TestRefreshCountsUpstreamFetches(t *testing.T) {
+	connector := confluent.Resource{ID: "lcc-a", ResourceType: "connector", Environment: "env-1"}
+	clock := newFakeClock()
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), connector}}
+	inv := New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Every type expires at once, concurrent requests start a single full
+	// refresh which the background and single type refreshes join
+	clock.Advance(2 * time.Minute)
+	release := make(chan struct{})
+	fetcher.mu.Lock()
+	fetcher.release = release
+	fetcher.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := inv.Resources(); err != nil {
+				t.Errorf("Failed to load resources: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	eventually(func() bool { return fetcher.fetchCount() == 2 })
+
+	refreshed := make(chan error, 2)
+	go func() { refreshed <- inv.Refresh() }()
+	go func() { refreshed <- inv.RefreshType("connector") }()
+	// Give the refreshes time to join the running fetch
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for n := 0; n < 2; n++ {
+		if err := <-refreshed; err != nil {
+			t.Errorf("Failed to refresh: %v", err)
+		}
+	}
+	if calls := fetcher.fetchCount(); calls != 2 {
+		t.Errorf("Expected 2 upstream fetches, got %d", calls)
+	}
+
+	// Types expiring together are fetched together
+	inv = New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{
+		CacheDuration: time.Hour,
+		TypeTTLs:      map[string]time.Duration{"connector": time.Minute, "ksql": time.Minute},
+		MaxStaleness:  time.Hour,
+	})
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+	if _, err := inv.Resources(); err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	eventually(func() bool { return fetcher.fetchCount() == 4 && !inv.Status().Refreshing })
+
+	fetcher.mu.Lock()
+	typeFetches, ksqlCalls := fetcher.typeFetches, fetcher.typeCalls["ksql"]
+	fetcher.mu.Unlock()
+	if typeFetches != 1 || ksqlCalls != 1 {
+		t.Errorf("Expected connectors and ksqlDB clusters in 1 fetch, got %d fetches", typeFetches)
+	}
+	if calls := fetcher.fetchCount(); calls != 4 {
+		t.Errorf("Expected 4 upstream fetches, got %d", calls)
+	}
+
+	// Requests beyond the max staleness join a running full refresh
+	inv = New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Minute})
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+
+	release = make(chan struct{})
+	fetcher.mu.Lock()
+	fetcher.release = release
+	fetcher.mu.Unlock()
+
+	go func() { refreshed <- inv.Refresh() }()
+	eventually(func() bool { return fetcher.fetchCount() == 6 })
+	go func() {
+		_, err := inv.Resources()
+		refreshed <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for n := 0; n < 2; n++ {
+		if err := <-refreshed; err != nil {
+			t.Errorf("Failed to refresh: %v", err)
+		}
+	}
+	if calls := fetcher.fetchCount(); calls != 6 {
+		t.Errorf("Expected 6 upstream fetches, got %d", calls)
+	}
+}
+
+func TestRefreshKeepsFailedSegments(t *testing.T) {
+	connector := confluent.Resource{ID: "lcc-a", ResourceType: "connector", Environment: "env-1"}
+	other := confluent.Resource{ID: "lkc-x", ResourceType: "kafka", Environment: "env-2"}
+
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), connector, other}}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	firstFetch := inv.Snapshot().FetchedAt
+
+	// Clusters of env-1 fail, so its Kafka and connector segments are kept
+	fetcher.mu.Lock()
+	fetcher.resources = []confluent.Resource{other}
+	fetcher.failed = map[confluent.Segment]error{
+		{EnvironmentID: "env-1", ResourceType: "kafka"}:     errors.New("internal server error"),
+		{EnvironmentID: "env-1", ResourceType: "connector"}: errors.New("internal server error"),
+	}
+	fetcher.mu.Unlock()
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	snapshot := inv.Snapshot()
+	if len(snapshot.Resources) != 3 {
+		t.Errorf("Expected 3 resources after merging failed segments, got %d", len(snapshot.Resources))
+	}
+
+	if len(snapshot.StaleSegments) != 2 {
+		t.Fatalf("Expected 2 stale segments, got %d", len(snapshot.StaleSegments))
+	}
+	if snapshot.StaleSegments[0].ResourceType != "connector" || snapshot.StaleSegments[1].ResourceType != "kafka" {
+		t.Errorf("Expected sorted connector and kafka segments, got %v", snapshot.StaleSegments)
+	}
+	if !snapshot.StaleSegments[0].Since.Equal(firstFetch) {
+		t.Errorf("Expected stale segment since %v, got %v", firstFetch, snapshot.StaleSegments[0].Since)
+	}
+
+	// A later failure keeps the time the segment was last fetched
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if since := inv.Snapshot().StaleSegments[0].Since; !since.Equal(firstFetch) {
+		t.Errorf("Expected stale segment since %v, got %v", firstFetch, since)
+	}
+}
+
+func TestRefreshKeepsFailedConnectorClusters(t *testing.T) {
+	connector := func(id, clusterID string) confluent.Resource {
+		return confluent.Resource{ID: id, ResourceType: "connector", Environment: "env-1", Labels: map[string]string{"cluster_id": clusterID}}
+	}
+
+	fetcher := &fakeFetcher{resources: []confluent.Resource{
+		kafka("lkc-a"), kafka("lkc-b"),
+		connector("sink", "lkc-a"), connector("deleted", "lkc-a"), connector("sink", "lkc-b"),
+	}}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// A connector is deleted from lkc-a while listing the connectors of lkc-b fails
+	fetcher.mu.Lock()
+	fetcher.resources = []confluent.Resource{kafka("lkc-a"), kafka("lkc-b"), connector("sink", "lkc-a")}
+	fetcher.failed = map[confluent.Segment]error{
+		{EnvironmentID: "env-1", ResourceType: "connector", ClusterID: "lkc-b"}: errors.New("internal server error"),
+	}
+	fetcher.mu.Unlock()
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	connectors := make(map[string]bool)
+	for _, resource := range inv.Snapshot().resourcesOfType("connector") {
+		connectors[resource.Labels["cluster_id"]+"/"+resource.ID] = true
+	}
+	expected := map[string]bool{"lkc-a/sink": true, "lkc-b/sink": true}
+	if !reflect.DeepEqual(connectors, expected) {
+		t.Errorf("Expected the deleted connector to stay deleted and lkc-b's connector to be kept, got %v", connectors)
+	}
+
+	staleSegments := inv.Snapshot().StaleSegments
+	if len(staleSegments) != 1 || staleSegments[0].ClusterID != "lkc-b" {
+		t.Errorf("Expected lkc-b's connectors to be stale, got %+v", staleSegments)
+	}
+}
+
+func TestRefreshShrinkGuard(t *testing.T) {
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), kafka("lkc-b"), kafka("lkc-c"), kafka("lkc-d")}}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour, MaxDropPercent: 50})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Losing half of the resources is within the limit
+	fetcher.set([]confluent.Resource{kafka("lkc-a"), kafka("lkc-b")}, nil)
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Expected refresh within the limit to be accepted, got %v", err)
+	}
+
+	// Losing everything is rejected and keeps the snapshot
+	fetcher.set(nil, nil)
+	if err := inv.Refresh(); !errors.Is(err, ErrShrinkRejected) {
+		t.Fatalf("Expected ErrShrinkRejected, got %v", err)
+	}
+	if count := len(inv.Snapshot().Resources); count != 2 {
+		t.Errorf("Expected the previous 2 resources to be kept, got %d", count)
+	}
+
+	shrink := inv.Status().PendingShrink
+	if shrink == nil {
+		t.Fatal("Expected a pending shrink")
+	}
+	if shrink.PreviousCount != 2 || shrink.NewCount != 0 || shrink.Removed["kafka"] != 2 {
+		t.Errorf("Unexpected shrink report: %+v", shrink)
+	}
+
+	if !inv.AcceptShrink() {
+		t.Fatal("Expected the pending shrink to be accepted")
+	}
+	if count := len(inv.Snapshot().Resources); count != 0 {
+		t.Errorf("Expected the accepted empty snapshot, got %d resources", count)
+	}
+	if inv.Status().PendingShrink != nil {
+		t.Errorf("Expected no pending shrink after acceptance")
+	}
+	if inv.AcceptShrink() {
+		t.Errorf("Expected nothing to accept")
+	}
+}
+
+func TestRefreshShrinkGuardPerType(t *testing.T) {
+	connector := confluent.Resource{ID: "lcc-a", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-a"}}
+	pool := confluent.Resource{ID: "lfcp-a", ResourceType: "compute_pool"}
+	clusters := []confluent.Resource{kafka("lkc-a"), kafka("lkc-b"), kafka("lkc-c"), kafka("lkc-d")}
+
+	fetcher := &fakeFetcher{resources: append([]confluent.Resource{connector}, clusters...)}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: time.Hour, MaxDropPercent: 50})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Losing the only connector is rejected although the whole inventory
+	// only shrinks by a fifth, while the new compute pool is served
+	fetcher.set(append([]confluent.Resource{pool}, clusters...), nil)
+	if err := inv.Refresh(); !errors.Is(err, ErrShrinkRejected) {
+		t.Fatalf("Expected ErrShrinkRejected, got %v", err)
+	}
+	if connectors := inv.Snapshot().resourcesOfType("connector"); len(connectors) != 1 {
+		t.Errorf("Expected the previous connector to be kept, got %v", connectors)
+	}
+	if pools := inv.Snapshot().resourcesOfType("compute_pool"); len(pools) != 1 {
+		t.Errorf("Expected the accepted compute pool, got %v", pools)
+	}
+
+	// Refreshing another type keeps the rejected connectors pending
+	fetcher.set(append([]confluent.Resource{pool}, clusters[:3]...), nil)
+	if err := inv.RefreshType("kafka"); err != nil {
+		t.Fatalf("Failed to refresh kafka: %v", err)
+	}
+	shrink := inv.Status().PendingShrink
+	if shrink == nil {
+		t.Fatal("Expected the connector shrink to stay pending")
+	}
+	if shrink.PreviousCount != 1 || shrink.NewCount != 0 || !reflect.DeepEqual(shrink.Removed, map[string]int{"connector": 1}) {
+		t.Errorf("Unexpected shrink report: %+v", shrink)
+	}
+
+	// Accepting keeps the Kafka clusters refreshed since the rejection
+	if !inv.AcceptShrink() {
+		t.Fatal("Expected the pending shrink to be accepted")
+	}
+	snapshot := inv.Snapshot()
+	if connectors := snapshot.resourcesOfType("connector"); len(connectors) != 0 {
+		t.Errorf("Expected the connector to be removed, got %v", connectors)
+	}
+	if count := len(snapshot.resourcesOfType("kafka")); count != 3 {
+		t.Errorf("Expected the 3 refreshed Kafka clusters, got %d", count)
+	}
+	if inv.Status().LastError != "" {
+		t.Errorf("Expected no error after acceptance, got %q", inv.Status().LastError)
+	}
+}
+
+func TestResourcesServesPendingShrink(t *testing.T) {
+	clock := newFakeClock()
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), kafka("lkc-b")}}
+	inv := New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{CacheDuration: time.Minute, MaxStaleness: 10 * time.Minute, MaxDropPercent: 50})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// The background refresh after expiry is rejected
+	fetcher.set(nil, nil)
+	clock.Advance(2 * time.Minute)
+	if _, err := inv.Resources(); err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	if !eventually(func() bool { return inv.Status().PendingShrink != nil && !inv.Status().Refreshing }) {
+		t.Fatal("Expected a pending shrink")
+	}
+	detectedAt := inv.Status().PendingShrink.DetectedAt
+
+	// The served resources are cached again, so requests don't refetch them
+	if _, err := inv.Resources(); err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	if calls := fetcher.fetchCount(); calls != 2 {
+		t.Errorf("Expected 2 upstream fetches, got %d", calls)
+	}
+
+	// Beyond the max staleness the previous resources are still served
+	clock.Advance(10 * time.Minute)
+	resources, err := inv.Resources()
+	if err != nil {
+		t.Fatalf("Expected the previous resources while the shrink is pending, got %v", err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Expected the 2 previous resources, got %d", len(resources))
+	}
+	if shrink := inv.Status().PendingShrink; shrink == nil || !shrink.DetectedAt.Equal(detectedAt) {
+		t.Errorf("Expected the shrink detected at %v to stay pending, got %+v", detectedAt, shrink)
+	}
+}
+
+func TestResourceTypeTTL(t *testing.T) {
+	connector := confluent.Resource{ID: "lcc-a", ResourceType: "connector", Environment: "env-1"}
+	clock := newFakeClock()
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), connector}}
+	inv := New(fetcher, newTestCache(t, clock), enrich.NewPipeline(), Options{
+		CacheDuration: time.Hour,
+		TypeTTLs:      map[string]time.Duration{"connector": time.Minute},
+		MaxStaleness:  time.Hour,
+	})
+
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	kafkaFetchedAt := inv.Snapshot().TypeFetchedAt["kafka"]
+
+	// Only the connectors expire and are refreshed on their own
+	clock.Advance(2 * time.Minute)
+	added := confluent.Resource{ID: "lcc-b", ResourceType: "connector", Environment: "env-1"}
+	fetcher.set([]confluent.Resource{kafka("lkc-a"), kafka("lkc-b"), connector, added}, nil)
+
+	if !inv.Stale() {
+		t.Errorf("Expected the expired connectors to be reported as stale")
+	}
+	resources, err := inv.Resources()
+	if err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Expected the cached Kafka cluster and stale connector, got %d resources", len(resources))
+	}
+
+	eventually(func() bool { return len(inv.Snapshot().resourcesOfType("connector")) == 2 })
+
+	snapshot := inv.Snapshot()
+	if count := len(snapshot.resourcesOfType("connector")); count != 2 {
+		t.Fatalf("Expected the connector refresh to find 2 connectors, got %d", count)
+	}
+	if count := len(snapshot.resourcesOfType("kafka")); count != 1 {
+		t.Errorf("Expected the Kafka clusters to be kept from the first fetch, got %d", count)
+	}
+	if !snapshot.TypeFetchedAt["kafka"].Equal(kafkaFetchedAt) || !snapshot.FetchedAt.Equal(kafkaFetchedAt) {
+		t.Errorf("Expected the Kafka fetch time %v to be kept as the oldest, got %v", kafkaFetchedAt, snapshot.FetchedAt)
+	}
+	if !snapshot.TypeFetchedAt["connector"].After(kafkaFetchedAt) {
+		t.Errorf("Expected a newer connector fetch time, got %v", snapshot.TypeFetchedAt["connector"])
+	}
+
+	if calls := fetcher.callCount(); calls != 1 {
+		t.Errorf("Expected 1 full fetch, got %d", calls)
+	}
+	if calls := fetcher.typeCallCount("connector"); calls != 1 {
+		t.Errorf("Expected 1 connector fetch, got %d", calls)
+	}
+	if calls := fetcher.typeCallCount("kafka"); calls != 0 {
+		t.Errorf("Expected no Kafka fetch, got %d", calls)
+	}
+
+	fetcher.mu.Lock()
+	clusters := fetcher.clusters
+	fetcher.mu.Unlock()
+	if len(clusters) != 1 || clusters[0].ID != "lkc-a" {
+		t.Errorf("Expected connectors to be listed from the known Kafka clusters, got %v", clusters)
+	}
+}
+
+func TestExpiryJitter(t *testing.T) {
+	inv := New(&fakeFetcher{}, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{
+		CacheDuration: time.Hour,
+		TypeTTLs:      map[string]time.Duration{"connector": time.Minute},
+		JitterPercent: 10,
+	})
+
+	for n := 0; n < 100; n++ {
+		if expiry := inv.expiry("connector"); expiry < time.Minute || expiry >= 66*time.Second {
+			t.Fatalf("Expected connector expiry within 10%% above 1m, got %v", expiry)
+		}
+		if expiry := inv.expiry("kafka"); expiry < time.Hour || expiry >= 66*time.Minute {
+			t.Fatalf("Expected kafka expiry within 10%% above 1h, got %v", expiry)
+		}
+	}
+
+	inv.options.JitterPercent = 0
+	if expiry := inv.expiry("connector"); expiry != time.Minute {
+		t.Errorf("Expected no jitter, got %v", expiry)
+	}
+}
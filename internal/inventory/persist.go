@@ -0,0 +1,109 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+const (
+	// snapshotSchemaVersion is bumped whenever the snapshot file format changes
+	snapshotSchemaVersion = 1
+)
+
+// snapshotFile is the on-disk format of a snapshot
+type snapshotFile struct {
+	SchemaVersion int                  `json:"schema_version"`
+	FetchedAt     time.Time            `json:"fetched_at"`
+	TypeFetchedAt map[string]time.Time `json:"type_fetched_at,omitempty"`
+	Resources     []confluent.Resource `json:"resources"`
+	StaleSegments []StaleSegment       `json:"stale_segments,omitempty"`
+}
+
+// LoadSnapshot reads the snapshot persisted at the configured path. It is
+// served as expired, so the first request triggers a background refresh
+// instead of waiting for the Confluent API. A missing file is not an error.
+func (i *Inventory) LoadSnapshot() error {
+	if i.options.SnapshotPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(i.options.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("No snapshot found at %s, starting cold", i.options.SnapshotPath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot %s: %w", i.options.SnapshotPath, err)
+	}
+
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse snapshot %s: %w", i.options.SnapshotPath, err)
+	}
+	if file.SchemaVersion != snapshotSchemaVersion {
+		return fmt.Errorf("snapshot %s has schema version %d, expected %d", i.options.SnapshotPath, file.SchemaVersion, snapshotSchemaVersion)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// A snapshot fetched in the meantime is newer
+	if i.snapshot != nil {
+		return nil
+	}
+
+	i.snapshot = &Snapshot{
+		Resources:     file.Resources,
+		FetchedAt:     file.FetchedAt,
+		TypeFetchedAt: file.TypeFetchedAt,
+		StaleSegments: file.StaleSegments,
+	}
+	log.Printf("Loaded snapshot of %d resources fetched %v ago from %s", len(file.Resources), i.since(file.FetchedAt).Round(time.Second), i.options.SnapshotPath)
+	return nil
+}
+
+// saveSnapshot atomically writes the snapshot to the configured path
+func (i *Inventory) saveSnapshot(snapshot *Snapshot) error {
+	if i.options.SnapshotPath == "" || snapshot == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(snapshotFile{
+		SchemaVersion: snapshotSchemaVersion,
+		FetchedAt:     snapshot.FetchedAt,
+		TypeFetchedAt: snapshot.TypeFetchedAt,
+		Resources:     snapshot.Resources,
+		StaleSegments: snapshot.StaleSegments,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(i.options.SnapshotPath), "."+filepath.Base(i.options.SnapshotPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	// Rename is atomic, so a crash never leaves a partially written snapshot
+	if err := os.Rename(tmpName, i.options.SnapshotPath); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/enrich"
+)
+
+func TestSnapshotPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	options := Options{CacheDuration: time.Minute, MaxStaleness: time.Hour, SnapshotPath: path}
+
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), kafka("lkc-b")}}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), options)
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	fetchedAt := inv.Snapshot().FetchedAt
+
+	// A restarted inventory serves the persisted snapshot without waiting for a fetch
+	restartedFetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a")}, release: make(chan struct{})}
+	defer close(restartedFetcher.release)
+
+	restarted := New(restartedFetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), options)
+	if err := restarted.LoadSnapshot(); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	resources, err := restarted.Resources()
+	if err != nil {
+		t.Fatalf("Failed to load resources: %v", err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Expected 2 persisted resources, got %d", len(resources))
+	}
+	if resources[0].Environment != "env-1" || resources[0].Labels["cluster_name"] != "lkc-a" {
+		t.Errorf("Expected persisted resource details, got %+v", resources[0])
+	}
+	if !restarted.Snapshot().FetchedAt.Equal(fetchedAt) {
+		t.Errorf("Expected persisted fetch time %v, got %v", fetchedAt, restarted.Snapshot().FetchedAt)
+	}
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	inv := New(&fakeFetcher{}, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{SnapshotPath: filepath.Join(dir, "missing.json")})
+	if err := inv.LoadSnapshot(); err != nil {
+		t.Errorf("Expected a missing snapshot to be ignored, got %v", err)
+	}
+
+	path := filepath.Join(dir, "old.json")
+	if err := os.WriteFile(path, []byte(`{"schema_version": 0, "resources": []}`), 0o644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	inv = New(&fakeFetcher{}, newTestCache(t, newFakeClock()), enrich.NewPipeline(), Options{SnapshotPath: path})
+	if err := inv.LoadSnapshot(); err == nil {
+		t.Errorf("Expected error for an unknown schema version, got nil")
+	}
+	if inv.Snapshot() != nil {
+		t.Errorf("Expected no snapshot to be loaded")
+	}
+}
+
+func TestSnapshotPersistenceKeepsLatest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	options := Options{CacheDuration: time.Minute, MaxStaleness: time.Hour, SnapshotPath: path}
+
+	connector := confluent.Resource{ID: "lcc-a", ResourceType: "connector", Environment: "env-1"}
+	fetcher := &fakeFetcher{resources: []confluent.Resource{kafka("lkc-a"), connector}}
+	inv := New(fetcher, newTestCache(t, newFakeClock()), enrich.NewPipeline(), options)
+	if err := inv.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Concurrent refreshes of different types persist in turn
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inv.RefreshType(confluent.ResourceTypes[n%len(confluent.ResourceTypes)])
+		}()
+	}
+	wg.Wait()
+
+	restarted := New(&fakeFetcher{}, newTestCache(t, newFakeClock()), enrich.NewPipeline(), options)
+	if err := restarted.LoadSnapshot(); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	for resourceType, fetchedAt := range inv.Snapshot().TypeFetchedAt {
+		if persisted := restarted.Snapshot().TypeFetchedAt[resourceType]; !persisted.Equal(fetchedAt) {
+			t.Errorf("Expected the latest %s fetch time %v on disk, got %v", resourceType, fetchedAt, persisted)
+		}
+	}
+}
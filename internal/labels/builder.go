@@ -0,0 +1,40 @@
+package labels
+
+import (
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+// Builder assembles the final, unprefixed label set for a resource
+type Builder struct {
+	templates  *Templates
+	normalizer *Normalizer
+}
+
+// NewBuilder creates a builder from optional templates and normalizer
+func NewBuilder(templates *Templates, normalizer *Normalizer) *Builder {
+	return &Builder{
+		templates:  templates,
+		normalizer: normalizer,
+	}
+}
+
+// Build merges the resource labels with rendered template labels and
+// normalises the result. Template labels override resource labels.
+func (b *Builder) Build(resource confluent.Resource) map[string]string {
+	var templates *Templates
+	var normalizer *Normalizer
+	if b != nil {
+		templates = b.templates
+		normalizer = b.normalizer
+	}
+
+	merged := make(map[string]string, len(resource.Labels))
+	for k, v := range resource.Labels {
+		merged[k] = v
+	}
+	for k, v := range templates.Render(resource) {
+		merged[k] = v
+	}
+
+	return normalizer.Normalize(merged)
+}
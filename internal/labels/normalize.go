@@ -0,0 +1,154 @@
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// CasingPreserve leaves label values untouched
+	CasingPreserve = "preserve"
+	// CasingLower lowercases label values
+	CasingLower = "lower"
+	// CasingUpper uppercases label values
+	CasingUpper = "upper"
+
+	// slugSuffix is appended to the label name of slug variants
+	slugSuffix = "_slug"
+)
+
+// Normalizer turns arbitrary label sets into ones that are safe to hand to Prometheus
+type Normalizer struct {
+	casing         string
+	maxValueLength int
+	slugLabels     []string
+}
+
+// NewNormalizer creates a normalizer. slugLabels lists labels that get an
+// additional "<name>_slug" variant, maxValueLength of 0 disables truncation.
+func NewNormalizer(casing string, maxValueLength int, slugLabels []string) (*Normalizer, error) {
+	switch casing {
+	case "":
+		casing = CasingPreserve
+	case CasingPreserve, CasingLower, CasingUpper:
+	default:
+		return nil, fmt.Errorf("invalid casing %q, must be %q, %q or %q", casing, CasingPreserve, CasingLower, CasingUpper)
+	}
+
+	if maxValueLength < 0 {
+		return nil, fmt.Errorf("invalid max value length %d, must not be negative", maxValueLength)
+	}
+
+	return &Normalizer{
+		casing:         casing,
+		maxValueLength: maxValueLength,
+		slugLabels:     slugLabels,
+	}, nil
+}
+
+// Normalize returns a copy of the labels with slug variants added, values
+// cased and truncated, and names sanitised. A nil normalizer only sanitises names.
+// When two names sanitise to the same result the first in sorted order wins.
+func (n *Normalizer) Normalize(labels map[string]string) map[string]string {
+	source := labels
+	if n != nil && len(n.slugLabels) > 0 {
+		source = make(map[string]string, len(labels)+len(n.slugLabels))
+		for k, v := range labels {
+			source[k] = v
+		}
+		for _, name := range n.slugLabels {
+			if value, ok := labels[name]; ok {
+				source[name+slugSuffix] = Slugify(value)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(source))
+	for k := range source {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	normalized := make(map[string]string, len(source))
+	for _, name := range names {
+		sanitized := SanitizeName(name)
+		if _, exists := normalized[sanitized]; exists {
+			continue
+		}
+		normalized[sanitized] = n.normalizeValue(source[name])
+	}
+
+	return normalized
+}
+
+// normalizeValue applies the casing policy and length limit to a value
+func (n *Normalizer) normalizeValue(value string) string {
+	if n == nil {
+		return value
+	}
+
+	switch n.casing {
+	case CasingLower:
+		value = strings.ToLower(value)
+	case CasingUpper:
+		value = strings.ToUpper(value)
+	}
+
+	if n.maxValueLength > 0 && utf8.RuneCountInString(value) > n.maxValueLength {
+		value = string([]rune(value)[:n.maxValueLength])
+	}
+
+	return value
+}
+
+// SanitizeName converts a string into a valid Prometheus label name by
+// replacing invalid characters with underscores and guarding leading digits.
+// Names starting with "__" are reserved for Prometheus, e.g. __address__ or
+// __param_*, so that prefix is removed.
+func SanitizeName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	sanitized := b.String()
+	for strings.HasPrefix(sanitized, "__") {
+		sanitized = strings.TrimPrefix(sanitized, "__")
+	}
+
+	switch {
+	case sanitized == "":
+		return "_"
+	case sanitized[0] >= '0' && sanitized[0] <= '9':
+		return "_" + sanitized
+	}
+	return sanitized
+}
+
+// Slugify lowercases a value and collapses every run of characters other
+// than letters and digits into a single hyphen
+func Slugify(value string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		} else {
+			pendingHyphen = true
+		}
+	}
+
+	return b.String()
+}
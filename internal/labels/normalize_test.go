@@ -0,0 +1,93 @@
+package labels
+
+import (
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"cluster_name":  "cluster_name",
+		"cost-center":   "cost_center",
+		"1st_owner":     "_1st_owner",
+		"team.name":     "team_name",
+		"":              "_",
+		"région":        "r_gion",
+		"ClusterName42": "ClusterName42",
+		"__address__":   "address__",
+		"__param_id":    "param_id",
+		"..scheme":      "scheme",
+		"____":          "_",
+		"__1st":         "_1st",
+		"_private":      "_private",
+	}
+
+	for input, expected := range tests {
+		if got := SanitizeName(input); got != expected {
+			t.Errorf("SanitizeName(%q): expected '%s', got '%s'", input, expected, got)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Payments Prod (EU)": "payments-prod-eu",
+		"orders_prod":        "orders-prod",
+		"--main--":           "main",
+	}
+
+	for input, expected := range tests {
+		if got := Slugify(input); got != expected {
+			t.Errorf("Slugify(%q): expected '%s', got '%s'", input, expected, got)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	normalizer, err := NewNormalizer(CasingLower, 8, []string{"cluster_name"})
+	if err != nil {
+		t.Fatalf("Failed to create normalizer: %v", err)
+	}
+
+	normalized := normalizer.Normalize(map[string]string{
+		"cluster_name": "Payments Prod",
+		"cost-center":  "CC-4711",
+	})
+
+	// Values are lowercased and truncated
+	if normalized["cluster_name"] != "payments" {
+		t.Errorf("Expected cluster_name 'payments', got '%s'", normalized["cluster_name"])
+	}
+
+	// Slug variants are derived from the original value and truncated like
+	// any other value, "payments-prod" to "payments"
+	if normalized["cluster_name_slug"] != "payments" {
+		t.Errorf("Expected cluster_name_slug 'payments', got '%s'", normalized["cluster_name_slug"])
+	}
+
+	// Names are sanitised
+	if normalized["cost_center"] != "cc-4711" {
+		t.Errorf("Expected cost_center 'cc-4711', got '%s'", normalized["cost_center"])
+	}
+	if _, found := normalized["cost-center"]; found {
+		t.Error("Expected invalid label name 'cost-center' to be replaced")
+	}
+}
+
+func TestNormalizeNil(t *testing.T) {
+	var normalizer *Normalizer
+
+	normalized := normalizer.Normalize(map[string]string{"team.name": "Payments"})
+	if normalized["team_name"] != "Payments" {
+		t.Errorf("Expected nil normalizer to only sanitise names, got %v", normalized)
+	}
+}
+
+func TestNewNormalizerInvalid(t *testing.T) {
+	if _, err := NewNormalizer("title", 0, nil); err == nil {
+		t.Error("Expected an error for an invalid casing, got nil")
+	}
+
+	if _, err := NewNormalizer("", -1, nil); err == nil {
+		t.Error("Expected an error for a negative max value length, got nil")
+	}
+}
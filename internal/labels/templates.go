@@ -0,0 +1,78 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+// templateFuncs are the helper functions available inside label templates
+var templateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"trim":    strings.TrimSpace,
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+}
+
+// Templates holds compiled label templates keyed by label name
+type Templates struct {
+	names     []string
+	templates map[string]*template.Template
+}
+
+// NewTemplates compiles the given label name to template text definitions
+func NewTemplates(definitions map[string]string) (*Templates, error) {
+	t := &Templates{
+		templates: make(map[string]*template.Template),
+	}
+
+	for name, text := range definitions {
+		tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for label %s: %w", name, err)
+		}
+		t.templates[name] = tmpl
+		t.names = append(t.names, name)
+	}
+
+	// Keep evaluation order stable across requests
+	sort.Strings(t.names)
+
+	return t, nil
+}
+
+// Render evaluates every template against the resource and returns the resulting labels.
+// Templates that fail or render to an empty string are skipped.
+func (t *Templates) Render(resource confluent.Resource) map[string]string {
+	if t == nil || len(t.names) == 0 {
+		return nil
+	}
+
+	rendered := make(map[string]string, len(t.names))
+	var buf bytes.Buffer
+
+	for _, name := range t.names {
+		buf.Reset()
+		if err := t.templates[name].Execute(&buf, resource); err != nil {
+			log.Printf("Warning: failed to render label template %s for resource %s: %v", name, resource.ID, err)
+			continue
+		}
+
+		if value := buf.String(); value != "" {
+			rendered[name] = value
+		}
+	}
+
+	return rendered
+}
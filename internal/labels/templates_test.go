@@ -0,0 +1,55 @@
+package labels
+
+import (
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := NewTemplates(map[string]string{
+		"service":  "{{ .Labels.environment_name }}-{{ .Labels.cluster_name | lower }}",
+		"resource": "{{ .ResourceType }}/{{ .ID }}",
+		"missing":  "{{ .Labels.does_not_exist }}",
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile templates: %v", err)
+	}
+
+	resource := confluent.Resource{
+		ID:           "lkc-abc123",
+		ResourceType: "kafka",
+		Labels: map[string]string{
+			"environment_name": "prod",
+			"cluster_name":     "Payments",
+		},
+	}
+
+	rendered := templates.Render(resource)
+
+	if rendered["service"] != "prod-payments" {
+		t.Errorf("Expected service label 'prod-payments', got '%s'", rendered["service"])
+	}
+
+	if rendered["resource"] != "kafka/lkc-abc123" {
+		t.Errorf("Expected resource label 'kafka/lkc-abc123', got '%s'", rendered["resource"])
+	}
+
+	// Empty results should not produce a label
+	if _, found := rendered["missing"]; found {
+		t.Error("Expected template rendering to an empty string to be skipped")
+	}
+}
+
+func TestTemplatesInvalid(t *testing.T) {
+	if _, err := NewTemplates(map[string]string{"broken": "{{ .Labels"}); err == nil {
+		t.Error("Expected an error for an invalid template, got nil")
+	}
+}
+
+func TestTemplatesNil(t *testing.T) {
+	var templates *Templates
+	if rendered := templates.Render(confluent.Resource{}); rendered != nil {
+		t.Errorf("Expected nil templates to render nothing, got %v", rendered)
+	}
+}
@@ -0,0 +1,300 @@
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// DefaultTarget is the Confluent Cloud Metrics API host
+	DefaultTarget = "api.telemetry.confluent.cloud"
+
+	defaultMetricsPath    = "/v2/metrics/cloud/export"
+	defaultSecretName     = "confluent-cloud-credentials"
+	defaultUsernameKey    = "api-key"
+	defaultPasswordKey    = "api-secret"
+	defaultNamePrefix     = "confluent"
+	maxResourceNameLength = 253
+)
+
+// SecretKeySelector references a key of a Kubernetes secret
+type SecretKeySelector struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+// BasicAuth references the secret keys holding basic auth credentials
+type BasicAuth struct {
+	Username SecretKeySelector `yaml:"username"`
+	Password SecretKeySelector `yaml:"password"`
+}
+
+// ObjectMeta is the subset of Kubernetes object metadata set on generated manifests
+type ObjectMeta struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+// StaticConfig is a static target group of a ScrapeConfig
+type StaticConfig struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty"`
+}
+
+// ScrapeConfigSpec is the subset of the ScrapeConfig spec used by this service
+type ScrapeConfigSpec struct {
+	StaticConfigs  []StaticConfig      `yaml:"staticConfigs"`
+	MetricsPath    string              `yaml:"metricsPath"`
+	Params         map[string][]string `yaml:"params,omitempty"`
+	Scheme         string              `yaml:"scheme"`
+	ScrapeInterval string              `yaml:"scrapeInterval,omitempty"`
+	BasicAuth      *BasicAuth          `yaml:"basicAuth,omitempty"`
+}
+
+// ScrapeConfig is a monitoring.coreos.com/v1alpha1 ScrapeConfig
+type ScrapeConfig struct {
+	APIVersion string           `yaml:"apiVersion"`
+	Kind       string           `yaml:"kind"`
+	Metadata   ObjectMeta       `yaml:"metadata"`
+	Spec       ScrapeConfigSpec `yaml:"spec"`
+}
+
+// ProbeStaticConfig lists the static targets of a Probe
+type ProbeStaticConfig struct {
+	Static []string          `yaml:"static"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// ProbeTargets holds the targets of a Probe
+type ProbeTargets struct {
+	StaticConfig ProbeStaticConfig `yaml:"staticConfig"`
+}
+
+// ProberSpec points at the blackbox exporter
+type ProberSpec struct {
+	URL string `yaml:"url"`
+}
+
+// ProbeSpec is the subset of the Probe spec used by this service
+type ProbeSpec struct {
+	JobName  string       `yaml:"jobName"`
+	Prober   ProberSpec   `yaml:"prober"`
+	Module   string       `yaml:"module"`
+	Interval string       `yaml:"interval,omitempty"`
+	Targets  ProbeTargets `yaml:"targets"`
+}
+
+// Probe is a monitoring.coreos.com/v1 Probe
+type Probe struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   ObjectMeta `yaml:"metadata"`
+	Spec       ProbeSpec  `yaml:"spec"`
+}
+
+// Generator renders discovered resources as Prometheus Operator manifests
+type Generator struct {
+	cfg          config.ManifestsConfig
+	labelBuilder *labels.Builder
+	probeModules map[string]string
+}
+
+// NewGenerator creates a generator, filling in defaults for unset options
+func NewGenerator(cfg config.ManifestsConfig, labelBuilder *labels.Builder, probeModules map[string]string) *Generator {
+	if cfg.MetricsPath == "" {
+		cfg.MetricsPath = defaultMetricsPath
+	}
+	if cfg.SecretName == "" {
+		cfg.SecretName = defaultSecretName
+	}
+	if cfg.UsernameKey == "" {
+		cfg.UsernameKey = defaultUsernameKey
+	}
+	if cfg.PasswordKey == "" {
+		cfg.PasswordKey = defaultPasswordKey
+	}
+	if cfg.NamePrefix == "" {
+		cfg.NamePrefix = defaultNamePrefix
+	}
+
+	return &Generator{
+		cfg:          cfg,
+		labelBuilder: labelBuilder,
+		probeModules: probeModules,
+	}
+}
+
+// ScrapeConfigs creates one ScrapeConfig per resource. Each resource needs its
+// own object because the resource ID is passed as a scrape parameter.
+func (g *Generator) ScrapeConfigs(resources []confluent.Resource, assignment targets.Assignment, prefix string) []ScrapeConfig {
+	var scrapeConfigs []ScrapeConfig
+
+	for _, resource := range resources {
+		groups := targets.Format([]confluent.Resource{resource}, assignment, prefix, g.labelBuilder)
+		if len(groups) == 0 {
+			continue
+		}
+		group := groups[0]
+
+		scrapeConfigs = append(scrapeConfigs, ScrapeConfig{
+			APIVersion: "monitoring.coreos.com/v1alpha1",
+			Kind:       "ScrapeConfig",
+			Metadata: ObjectMeta{
+				Name:      g.uniqueName(paramsName(group.Params), targets.ResourceKey(resource)+"?"+url.Values(group.Params).Encode()),
+				Namespace: g.cfg.Namespace,
+				Labels:    g.cfg.Labels,
+			},
+			Spec: ScrapeConfigSpec{
+				StaticConfigs: []StaticConfig{
+					{
+						Targets: group.Targets,
+						Labels:  group.Labels,
+					},
+				},
+				MetricsPath:    g.cfg.MetricsPath,
+				Params:         group.Params,
+				Scheme:         "HTTPS",
+				ScrapeInterval: g.cfg.ScrapeInterval,
+				BasicAuth: &BasicAuth{
+					Username: SecretKeySelector{Name: g.cfg.SecretName, Key: g.cfg.UsernameKey},
+					Password: SecretKeySelector{Name: g.cfg.SecretName, Key: g.cfg.PasswordKey},
+				},
+			},
+		})
+	}
+
+	return scrapeConfigs
+}
+
+// Probes creates one Probe per resource endpoint for the blackbox exporter
+func (g *Generator) Probes(resources []confluent.Resource, prefix string) ([]Probe, error) {
+	if g.cfg.ProberURL == "" {
+		return nil, fmt.Errorf("manifests prober_url must be set to generate Probe manifests")
+	}
+
+	var probes []Probe
+
+	for _, group := range targets.FormatProbes(resources, prefix, "", g.probeModules, g.labelBuilder) {
+		module := group.Labels["probe_module"]
+		identity := strings.Join([]string{
+			group.Labels[prefix+"resource_type"],
+			group.Labels[prefix+"cluster_id"],
+			group.Labels[prefix+"resource_id"],
+			group.Labels[prefix+"endpoint"],
+			strings.Join(group.Targets, ","),
+		}, "/")
+		name := g.uniqueName("probe-"+group.Labels[prefix+"resource_id"]+"-"+group.Labels[prefix+"endpoint"], identity)
+
+		probes = append(probes, Probe{
+			APIVersion: "monitoring.coreos.com/v1",
+			Kind:       "Probe",
+			Metadata: ObjectMeta{
+				Name:      name,
+				Namespace: g.cfg.Namespace,
+				Labels:    g.cfg.Labels,
+			},
+			Spec: ProbeSpec{
+				JobName:  g.cfg.NamePrefix + "-probes",
+				Prober:   ProberSpec{URL: g.cfg.ProberURL},
+				Module:   module,
+				Interval: g.cfg.ScrapeInterval,
+				Targets: ProbeTargets{
+					StaticConfig: ProbeStaticConfig{
+						Static: group.Targets,
+						Labels: group.Labels,
+					},
+				},
+			},
+		})
+	}
+
+	return probes, nil
+}
+
+// Render encodes the manifests as a multi-document YAML stream
+func Render(objects ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	for _, object := range objects {
+		if err := encoder.Encode(object); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// objectName builds a valid Kubernetes object name from the configured prefix and a suffix
+func (g *Generator) objectName(suffix string) string {
+	name := dnsName(g.cfg.NamePrefix + "-" + suffix)
+	if len(name) > maxResourceNameLength {
+		name = strings.TrimRight(name[:maxResourceNameLength], "-.")
+	}
+	return name
+}
+
+// uniqueName builds an object name like objectName, ending in a short hash
+// of the identity. Suffixes that only differ in characters dnsName replaces,
+// or connectors with the same name in different clusters, get distinct names.
+func (g *Generator) uniqueName(suffix, identity string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(identity))
+	hashSuffix := fmt.Sprintf("-%08x", hash.Sum32())
+
+	name := dnsName(g.cfg.NamePrefix + "-" + suffix)
+	if len(name) > maxResourceNameLength-len(hashSuffix) {
+		name = strings.TrimRight(name[:maxResourceNameLength-len(hashSuffix)], "-.")
+	}
+	return name + hashSuffix
+}
+
+// paramsName derives a name from the resource parameters, e.g. "kafka-lkc-abc123"
+func paramsName(params map[string][]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		resourceType := strings.TrimSuffix(strings.TrimPrefix(key, "resource."), ".id")
+		parts = append(parts, resourceType)
+		parts = append(parts, params[key]...)
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// dnsName lowercases a string and replaces characters that aren't allowed in
+// Kubernetes object names with hyphens
+func dnsName(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
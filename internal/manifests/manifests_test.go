@@ -0,0 +1,170 @@
+package manifests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+var testResources = []confluent.Resource{
+	{
+		ID:           "lkc-abc123",
+		ResourceType: "kafka",
+		Labels:       map[string]string{"cluster_name": "main"},
+		Endpoints:    map[string]string{"bootstrap": "SASL_SSL://pkc-123.aws.confluent.cloud:9092"},
+	},
+	{
+		ID:           "My_Sink Connector",
+		ResourceType: "connector",
+		Labels:       map[string]string{"connector_name": "My_Sink Connector"},
+	},
+}
+
+func TestScrapeConfigs(t *testing.T) {
+	generator := NewGenerator(config.ManifestsConfig{
+		Namespace: "monitoring",
+		Labels:    map[string]string{"release": "kube-prometheus-stack"},
+	}, nil, nil)
+
+	scrapeConfigs := generator.ScrapeConfigs(testResources, targets.Assignment{Fallback: []string{DefaultTarget}}, "confluent_")
+	if len(scrapeConfigs) != 2 {
+		t.Fatalf("Expected 2 ScrapeConfigs, got %d", len(scrapeConfigs))
+	}
+
+	kafka := scrapeConfigs[0]
+	if !hashedName(kafka.Metadata.Name, "confluent-kafka-lkc-abc123") {
+		t.Errorf("Expected name 'confluent-kafka-lkc-abc123-<hash>', got '%s'", kafka.Metadata.Name)
+	}
+	if kafka.Spec.MetricsPath != defaultMetricsPath {
+		t.Errorf("Expected export metrics path, got '%s'", kafka.Spec.MetricsPath)
+	}
+	if kafka.Spec.BasicAuth.Password.Name != defaultSecretName || kafka.Spec.BasicAuth.Password.Key != defaultPasswordKey {
+		t.Errorf("Expected default secret reference, got %+v", kafka.Spec.BasicAuth.Password)
+	}
+	if kafka.Spec.StaticConfigs[0].Labels["confluent_cluster_name"] != "main" {
+		t.Errorf("Expected prefixed labels in static config, got %v", kafka.Spec.StaticConfigs[0].Labels)
+	}
+
+	// Names are made safe for Kubernetes
+	if name := scrapeConfigs[1].Metadata.Name; !hashedName(name, "confluent-connector-my-sink-connector") {
+		t.Errorf("Expected name 'confluent-connector-my-sink-connector-<hash>', got '%s'", name)
+	}
+
+	content, err := Render(scrapeConfigs[0], scrapeConfigs[1])
+	if err != nil {
+		t.Fatalf("Failed to render manifests: %v", err)
+	}
+
+	rendered := string(content)
+	for _, expected := range []string{"kind: ScrapeConfig", "apiVersion: monitoring.coreos.com/v1alpha1", "resource.kafka.id:", "---", "namespace: monitoring"} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Expected rendered manifests to contain %q", expected)
+		}
+	}
+}
+
+// hashedName reports whether name is the base followed by an 8 character hash
+func hashedName(name, base string) bool {
+	return strings.HasPrefix(name, base+"-") && len(name) == len(base)+9
+}
+
+func TestScrapeConfigNamesAreUnique(t *testing.T) {
+	generator := NewGenerator(config.ManifestsConfig{}, nil, nil)
+
+	resources := []confluent.Resource{
+		// Connector names are only unique within a cluster
+		{ID: "orders-sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-a"}},
+		{ID: "orders-sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-b"}},
+		// Both normalise to "a-b"
+		{ID: "a_b", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-a"}},
+		{ID: "a-b", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-a"}},
+		{ID: strings.Repeat("x", 300), ResourceType: "kafka"},
+	}
+
+	scrapeConfigs := generator.ScrapeConfigs(resources, targets.Assignment{Fallback: []string{DefaultTarget}}, "")
+	if len(scrapeConfigs) != len(resources) {
+		t.Fatalf("Expected %d ScrapeConfigs, got %d", len(resources), len(scrapeConfigs))
+	}
+
+	names := make(map[string]bool)
+	for _, scrapeConfig := range scrapeConfigs {
+		name := scrapeConfig.Metadata.Name
+		if names[name] {
+			t.Errorf("Expected unique names, got %q twice", name)
+		}
+		if len(name) > maxResourceNameLength {
+			t.Errorf("Expected names of at most %d characters, got %d", maxResourceNameLength, len(name))
+		}
+		names[name] = true
+	}
+
+	// Names are stable across runs
+	again := generator.ScrapeConfigs(resources, targets.Assignment{Fallback: []string{DefaultTarget}}, "")
+	if again[0].Metadata.Name != scrapeConfigs[0].Metadata.Name {
+		t.Errorf("Expected stable names, got %q and %q", scrapeConfigs[0].Metadata.Name, again[0].Metadata.Name)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	generator := NewGenerator(config.ManifestsConfig{}, nil, map[string]string{"kafka": "tcp_connect_tls"})
+
+	if _, err := generator.Probes(testResources, ""); err == nil {
+		t.Error("Expected an error without prober_url, got nil")
+	}
+
+	generator = NewGenerator(config.ManifestsConfig{ProberURL: "blackbox-exporter:9115"}, nil, map[string]string{"kafka": "tcp_connect_tls"})
+
+	probes, err := generator.Probes(testResources, "")
+	if err != nil {
+		t.Fatalf("Failed to generate probes: %v", err)
+	}
+
+	if len(probes) != 1 {
+		t.Fatalf("Expected 1 Probe, got %d", len(probes))
+	}
+
+	probe := probes[0]
+	if probe.Spec.Module != "tcp_connect_tls" {
+		t.Errorf("Expected module 'tcp_connect_tls', got '%s'", probe.Spec.Module)
+	}
+	if probe.Spec.Targets.StaticConfig.Static[0] != "pkc-123.aws.confluent.cloud:9092" {
+		t.Errorf("Expected bootstrap address, got %v", probe.Spec.Targets.StaticConfig.Static)
+	}
+	if !hashedName(probe.Metadata.Name, "confluent-probe-lkc-abc123-bootstrap") {
+		t.Errorf("Expected name 'confluent-probe-lkc-abc123-bootstrap-<hash>', got '%s'", probe.Metadata.Name)
+	}
+}
+
+func TestProbeNamesAreUnique(t *testing.T) {
+	generator := NewGenerator(config.ManifestsConfig{ProberURL: "blackbox-exporter:9115"}, nil, nil)
+
+	resources := []confluent.Resource{
+		// Both normalise to "lkc-a"
+		{ID: "lkc_a", ResourceType: "kafka", Endpoints: map[string]string{"bootstrap": "SASL_SSL://pkc-1.aws.confluent.cloud:9092"}},
+		{ID: "lkc.a", ResourceType: "kafka", Endpoints: map[string]string{"bootstrap": "SASL_SSL://pkc-2.aws.confluent.cloud:9092"}},
+		{ID: strings.Repeat("x", 300), ResourceType: "kafka", Endpoints: map[string]string{"bootstrap": "SASL_SSL://pkc-3.aws.confluent.cloud:9092"}},
+	}
+
+	probes, err := generator.Probes(resources, "")
+	if err != nil {
+		t.Fatalf("Failed to generate probes: %v", err)
+	}
+	if len(probes) != len(resources) {
+		t.Fatalf("Expected %d Probes, got %d", len(resources), len(probes))
+	}
+
+	names := make(map[string]bool)
+	for _, probe := range probes {
+		name := probe.Metadata.Name
+		if names[name] {
+			t.Errorf("Expected unique names, got %q twice", name)
+		}
+		if len(name) > maxResourceNameLength {
+			t.Errorf("Expected names of at most %d characters, got %d", maxResourceNameLength, len(name))
+		}
+		names[name] = true
+	}
+}
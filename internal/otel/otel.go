@@ -0,0 +1,294 @@
+package otel
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// ModeStatic renders one scrape config with static targets per resource
+	ModeStatic = "static"
+	// ModeHTTPSD renders a single scrape config discovering targets from this service
+	ModeHTTPSD = "http_sd"
+
+	// DefaultTarget is the Confluent Cloud Metrics API host
+	DefaultTarget = "api.telemetry.confluent.cloud"
+
+	defaultJobName         = "confluent-cloud"
+	defaultMetricsPath     = "/v2/metrics/cloud/export"
+	defaultUsername        = "${env:CONFLUENT_API_KEY}"
+	defaultPassword        = "${env:CONFLUENT_API_SECRET}"
+	defaultRefreshInterval = "30m"
+
+	// OpenTelemetry semantic convention attributes
+	cloudProviderAttribute = "cloud.provider"
+	cloudRegionAttribute   = "cloud.region"
+)
+
+// BasicAuth holds the Metrics API credentials
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Authorization holds the bearer token for this service
+type Authorization struct {
+	Type        string `yaml:"type"`
+	Credentials string `yaml:"credentials"`
+}
+
+// StaticConfig is a static target group
+type StaticConfig struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty"`
+}
+
+// HTTPSDConfig points the receiver at the /discovery endpoint of this service
+type HTTPSDConfig struct {
+	URL             string         `yaml:"url"`
+	RefreshInterval string         `yaml:"refresh_interval,omitempty"`
+	Authorization   *Authorization `yaml:"authorization,omitempty"`
+}
+
+// RelabelConfig is a Prometheus relabeling step
+type RelabelConfig struct {
+	SourceLabels []string `yaml:"source_labels,omitempty"`
+	TargetLabel  string   `yaml:"target_label,omitempty"`
+	Regex        string   `yaml:"regex,omitempty"`
+	Action       string   `yaml:"action"`
+}
+
+// ScrapeConfig is a Prometheus scrape config inside the receiver
+type ScrapeConfig struct {
+	JobName        string              `yaml:"job_name"`
+	ScrapeInterval string              `yaml:"scrape_interval,omitempty"`
+	MetricsPath    string              `yaml:"metrics_path"`
+	Scheme         string              `yaml:"scheme"`
+	Params         map[string][]string `yaml:"params,omitempty"`
+	BasicAuth      BasicAuth           `yaml:"basic_auth"`
+	StaticConfigs  []StaticConfig      `yaml:"static_configs,omitempty"`
+	HTTPSDConfigs  []HTTPSDConfig      `yaml:"http_sd_configs,omitempty"`
+	RelabelConfigs []RelabelConfig     `yaml:"relabel_configs,omitempty"`
+}
+
+// ReceiverConfig is the embedded Prometheus configuration of the receiver
+type ReceiverConfig struct {
+	ScrapeConfigs []ScrapeConfig `yaml:"scrape_configs"`
+}
+
+// PrometheusReceiver is the OpenTelemetry Collector prometheus receiver
+type PrometheusReceiver struct {
+	Config ReceiverConfig `yaml:"config"`
+}
+
+// Receivers holds the collector receivers
+type Receivers struct {
+	Prometheus PrometheusReceiver `yaml:"prometheus"`
+}
+
+// Config is the collector configuration fragment rendered by the generator
+type Config struct {
+	Receivers Receivers `yaml:"receivers"`
+}
+
+// Generator renders discovered resources as OpenTelemetry Collector receiver configuration
+type Generator struct {
+	cfg          config.OTelConfig
+	labelBuilder *labels.Builder
+}
+
+// NewGenerator creates a generator, filling in defaults for unset options
+func NewGenerator(cfg config.OTelConfig, labelBuilder *labels.Builder) *Generator {
+	if cfg.JobName == "" {
+		cfg.JobName = defaultJobName
+	}
+	if cfg.MetricsPath == "" {
+		cfg.MetricsPath = defaultMetricsPath
+	}
+	if cfg.Username == "" {
+		cfg.Username = defaultUsername
+	}
+	if cfg.Password == "" {
+		cfg.Password = defaultPassword
+	}
+	if cfg.RefreshInterval == "" {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+
+	return &Generator{
+		cfg:          cfg,
+		labelBuilder: labelBuilder,
+	}
+}
+
+// Static renders one scrape config per resource, since each resource passes
+// its ID as a scrape parameter. Job names include the resource key, so
+// same-named connectors of different clusters get distinct jobs. With semanticConventions, the cloud provider
+// and region labels are renamed to their OpenTelemetry attributes.
+func (g *Generator) Static(resources []confluent.Resource, assignment targets.Assignment, prefix string, semanticConventions bool) Config {
+	var scrapeConfigs []ScrapeConfig
+
+	for _, resource := range resources {
+		groups := targets.Format([]confluent.Resource{resource}, assignment, prefix, g.labelBuilder)
+		if len(groups) == 0 {
+			continue
+		}
+		group := groups[0]
+
+		if semanticConventions {
+			mapSemanticConventions(group.Labels, prefix)
+		}
+
+		scrapeConfig := g.scrapeConfig(g.cfg.JobName + "-" + targets.ResourceKey(resource))
+		scrapeConfig.Params = group.Params
+		scrapeConfig.StaticConfigs = []StaticConfig{
+			{
+				Targets: group.Targets,
+				Labels:  group.Labels,
+			},
+		}
+		scrapeConfigs = append(scrapeConfigs, scrapeConfig)
+	}
+
+	return wrap(scrapeConfigs)
+}
+
+// HTTPSD renders a single scrape config discovering its targets from the
+// /discovery endpoint of this service with the given query. With
+// semanticConventions, relabeling renames the cloud provider and region labels.
+func (g *Generator) HTTPSD(query url.Values, semanticConventions bool) (Config, error) {
+	if g.cfg.ServiceURL == "" {
+		return Config{}, fmt.Errorf("otel service_url must be set to render http_sd configuration")
+	}
+
+	prefix, err := targets.ParsePrefix(query.Get("prefix"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	scrapeConfig := g.scrapeConfig(g.cfg.JobName)
+	scrapeConfig.HTTPSDConfigs = []HTTPSDConfig{
+		{
+			URL:             strings.TrimSuffix(g.cfg.ServiceURL, "/") + "/discovery?" + query.Encode(),
+			RefreshInterval: g.cfg.RefreshInterval,
+			Authorization: &Authorization{
+				Type:        "Bearer",
+				Credentials: g.cfg.Username,
+			},
+		},
+	}
+
+	if semanticConventions {
+		scrapeConfig.RelabelConfigs = semanticConventionRelabeling(prefix)
+	}
+
+	return wrap([]ScrapeConfig{scrapeConfig}), nil
+}
+
+// Render encodes the configuration as YAML
+func Render(cfg Config) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(cfg); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scrapeConfig creates a scrape config for the Metrics API with the shared settings
+func (g *Generator) scrapeConfig(jobName string) ScrapeConfig {
+	return ScrapeConfig{
+		JobName:        jobName,
+		ScrapeInterval: g.cfg.ScrapeInterval,
+		MetricsPath:    g.cfg.MetricsPath,
+		Scheme:         "https",
+		BasicAuth: BasicAuth{
+			Username: g.cfg.Username,
+			Password: g.cfg.Password,
+		},
+	}
+}
+
+// wrap nests the scrape configs in the receiver configuration
+func wrap(scrapeConfigs []ScrapeConfig) Config {
+	if scrapeConfigs == nil {
+		// Render an empty list rather than null
+		scrapeConfigs = []ScrapeConfig{}
+	}
+
+	return Config{
+		Receivers: Receivers{
+			Prometheus: PrometheusReceiver{
+				Config: ReceiverConfig{ScrapeConfigs: scrapeConfigs},
+			},
+		},
+	}
+}
+
+// mapSemanticConventions renames the cloud labels to OpenTelemetry attributes.
+// Provider values are lowercased to match the conventions, e.g. "aws".
+func mapSemanticConventions(targetLabels map[string]string, prefix string) {
+	if provider, ok := targetLabels[prefix+"cloud_provider"]; ok {
+		delete(targetLabels, prefix+"cloud_provider")
+		targetLabels[cloudProviderAttribute] = strings.ToLower(provider)
+	}
+	if region, ok := targetLabels[prefix+"region"]; ok {
+		delete(targetLabels, prefix+"region")
+		targetLabels[cloudRegionAttribute] = region
+	}
+}
+
+// semanticConventionRelabeling maps the cloud labels of discovered targets to
+// OpenTelemetry attributes and drops the originals
+func semanticConventionRelabeling(prefix string) []RelabelConfig {
+	mapping := map[string]string{
+		prefix + "cloud_provider": cloudProviderAttribute,
+		prefix + "region":         cloudRegionAttribute,
+	}
+
+	sources := make([]string, 0, len(mapping))
+	for source := range mapping {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var relabelConfigs []RelabelConfig
+	for _, source := range sources {
+		relabelConfigs = append(relabelConfigs, RelabelConfig{
+			SourceLabels: []string{source},
+			TargetLabel:  mapping[source],
+			Action:       "replace",
+		})
+	}
+
+	relabelConfigs = append(relabelConfigs,
+		RelabelConfig{
+			SourceLabels: []string{cloudProviderAttribute},
+			TargetLabel:  cloudProviderAttribute,
+			Action:       "lowercase",
+		},
+		RelabelConfig{
+			Regex:  strings.Join(sources, "|"),
+			Action: "labeldrop",
+		},
+	)
+
+	return relabelConfigs
+}
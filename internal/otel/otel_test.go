@@ -0,0 +1,95 @@
+package otel
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+func TestStatic(t *testing.T) {
+	resources := []confluent.Resource{
+		{
+			ID:           "lkc-abc123",
+			ResourceType: "kafka",
+			Labels:       map[string]string{"cloud_provider": "AWS", "region": "us-east-1", "cluster_name": "orders"},
+		},
+	}
+
+	generator := NewGenerator(config.OTelConfig{}, nil)
+	cfg := generator.Static(resources, targets.Assignment{Fallback: []string{DefaultTarget}}, "confluent_", true)
+
+	scrapeConfigs := cfg.Receivers.Prometheus.Config.ScrapeConfigs
+	if len(scrapeConfigs) != 1 {
+		t.Fatalf("Expected 1 scrape config, got %d", len(scrapeConfigs))
+	}
+
+	scrapeConfig := scrapeConfigs[0]
+	if scrapeConfig.JobName != "confluent-cloud-kafka-lkc-abc123" {
+		t.Errorf("Expected job name confluent-cloud-kafka-lkc-abc123, got %s", scrapeConfig.JobName)
+	}
+	if scrapeConfig.BasicAuth.Username != defaultUsername {
+		t.Errorf("Expected default username, got %s", scrapeConfig.BasicAuth.Username)
+	}
+
+	staticLabels := scrapeConfig.StaticConfigs[0].Labels
+	if staticLabels["cloud.provider"] != "aws" || staticLabels["cloud.region"] != "us-east-1" {
+		t.Errorf("Expected semantic convention attributes, got %v", staticLabels)
+	}
+	if _, ok := staticLabels["confluent_cloud_provider"]; ok {
+		t.Errorf("Expected confluent_cloud_provider to be renamed, got %v", staticLabels)
+	}
+	if staticLabels["confluent_cluster_name"] != "orders" {
+		t.Errorf("Expected confluent_cluster_name label, got %v", staticLabels)
+	}
+}
+
+func TestStaticConnectorJobNames(t *testing.T) {
+	// Connector names are only unique within their Kafka cluster
+	resources := []confluent.Resource{
+		{ID: "orders-sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-a"}},
+		{ID: "orders-sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-b"}},
+	}
+
+	generator := NewGenerator(config.OTelConfig{}, nil)
+	scrapeConfigs := generator.Static(resources, targets.Assignment{Fallback: []string{DefaultTarget}}, "", false).Receivers.Prometheus.Config.ScrapeConfigs
+	if len(scrapeConfigs) != 2 {
+		t.Fatalf("Expected 2 scrape configs, got %d", len(scrapeConfigs))
+	}
+
+	if scrapeConfigs[0].JobName != "confluent-cloud-connector-lkc-a-orders-sink" || scrapeConfigs[1].JobName != "confluent-cloud-connector-lkc-b-orders-sink" {
+		t.Errorf("Expected job names including the cluster ID, got %s and %s", scrapeConfigs[0].JobName, scrapeConfigs[1].JobName)
+	}
+}
+
+func TestHTTPSD(t *testing.T) {
+	generator := NewGenerator(config.OTelConfig{}, nil)
+	if _, err := generator.HTTPSD(url.Values{}, false); err == nil {
+		t.Errorf("Expected error without service_url, got nil")
+	}
+
+	generator = NewGenerator(config.OTelConfig{ServiceURL: "http://sd:8080/"}, nil)
+	cfg, err := generator.HTTPSD(url.Values{"targets": {DefaultTarget}}, true)
+	if err != nil {
+		t.Fatalf("Failed to render http_sd configuration: %v", err)
+	}
+
+	content, err := Render(cfg)
+	if err != nil {
+		t.Fatalf("Failed to render configuration: %v", err)
+	}
+
+	for _, expected := range []string{
+		"url: http://sd:8080/discovery?targets=api.telemetry.confluent.cloud",
+		"target_label: cloud.provider",
+		"action: lowercase",
+		"regex: cloud_provider|region",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected rendered configuration to contain %q, got:\n%s", expected, content)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package targets
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+)
+
+const (
+	// DefaultBatchLimit is the number of resource IDs per export request for types without a configured limit
+	DefaultBatchLimit = 50
+)
+
+// BatchLimits caps the number of resource IDs per export request by resource type
+type BatchLimits map[string]int
+
+// For returns the limit of a resource type, falling back to DefaultBatchLimit
+func (l BatchLimits) For(resourceType string) int {
+	if limit, ok := l[resourceType]; ok && limit > 0 {
+		return limit
+	}
+	return DefaultBatchLimit
+}
+
+// Validate checks that every limit applies to a known resource type and is positive
+func (l BatchLimits) Validate() error {
+	for resourceType, limit := range l {
+		if !IsResourceType(resourceType) {
+			return fmt.Errorf("unknown resource type %q in batch limits", resourceType)
+		}
+		if limit <= 0 {
+			return fmt.Errorf("batch limit for %s must be positive, got %d", resourceType, limit)
+		}
+	}
+	return nil
+}
+
+// Chunk sorts and de-duplicates the IDs and splits them into chunks of at
+// most limit IDs. The same IDs always produce the same chunks.
+func Chunk(ids []string, limit int) [][]string {
+	unique := make(map[string]bool, len(ids))
+	var sorted []string
+	for _, id := range ids {
+		if !unique[id] {
+			unique[id] = true
+			sorted = append(sorted, id)
+		}
+	}
+	sort.Strings(sorted)
+
+	if limit <= 0 {
+		limit = DefaultBatchLimit
+	}
+
+	var chunks [][]string
+	for start := 0; start < len(sorted); start += limit {
+		end := start + limit
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+		chunks = append(chunks, sorted[start:end])
+	}
+	return chunks
+}
+
+// Bucket is a group of resource IDs with a name that stays the same across refreshes
+type Bucket struct {
+	Name string
+	IDs  []string
+}
+
+// Buckets groups the IDs by a hash of each ID, so adding or removing an ID
+// doesn't move any other ID to another bucket. The bucket count is the power
+// of two keeping buckets about half full at the limit, so it only changes
+// when the number of IDs crosses a power of two. A bucket that exceeds the
+// limit anyway is split into sorted parts named "<bucket>-<part>".
+func Buckets(ids []string, limit int) []Bucket {
+	if limit <= 0 {
+		limit = DefaultBatchLimit
+	}
+
+	unique := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+
+	count := 1
+	for count*limit < 2*len(unique) {
+		count *= 2
+	}
+
+	grouped := make([][]string, count)
+	for id := range unique {
+		bucket := bucketOf(id, count)
+		grouped[bucket] = append(grouped[bucket], id)
+	}
+
+	var buckets []Bucket
+	for index, bucketIDs := range grouped {
+		if len(bucketIDs) == 0 {
+			continue
+		}
+		sort.Strings(bucketIDs)
+
+		if len(bucketIDs) <= limit {
+			buckets = append(buckets, Bucket{Name: fmt.Sprint(index), IDs: bucketIDs})
+			continue
+		}
+		for part, chunk := range Chunk(bucketIDs, limit) {
+			buckets = append(buckets, Bucket{Name: fmt.Sprintf("%d-%d", index, part), IDs: chunk})
+		}
+	}
+	return buckets
+}
+
+// bucketOf returns the bucket of an ID out of count buckets
+func bucketOf(id string, count int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(id))
+	return int(hash.Sum32() % uint32(count))
+}
+
+// FormatBatched builds one target group per bucket of resources of the same
+// type, passing every resource ID of the bucket as a scrape parameter. Groups
+// only carry the labels shared by all of their resources, plus the resource
+// type and a batch label keeping the groups of a type apart. Resources are
+// assigned to buckets by ResourceKey, so same-named connectors of different
+// clusters are kept apart and target identities stay stable across refreshes.
+func FormatBatched(resources []confluent.Resource, assignment Assignment, prefix string, labelBuilder *labels.Builder, limits BatchLimits) []Target {
+	byType := make(map[string]map[string]confluent.Resource)
+	for _, resource := range resources {
+		if byType[resource.ResourceType] == nil {
+			byType[resource.ResourceType] = make(map[string]confluent.Resource)
+		}
+		byType[resource.ResourceType][ResourceKey(resource)] = resource
+	}
+
+	var response []Target
+
+	for _, resourceType := range ResourceTypes {
+		resourceTargets := assignment.ForType(resourceType)
+		if len(resourceTargets) == 0 || len(byType[resourceType]) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(byType[resourceType]))
+		for key := range byType[resourceType] {
+			keys = append(keys, key)
+		}
+
+		for _, bucket := range Buckets(keys, limits.For(resourceType)) {
+			target := Target{
+				Targets: resourceTargets,
+				Labels:  make(map[string]string),
+				Params:  map[string][]string{"resource." + resourceType + ".id": resourceIDs(bucket.IDs, byType[resourceType])},
+			}
+
+			for k, v := range sharedLabels(bucket.IDs, byType[resourceType], labelBuilder) {
+				target.Labels[prefix+k] = v
+			}
+			target.Labels[prefix+"resource_type"] = resourceType
+			target.Labels[prefix+"batch"] = resourceType + "-" + bucket.Name
+
+			response = append(response, target)
+		}
+	}
+
+	return response
+}
+
+// resourceIDs returns the sorted, distinct IDs of the resources with the keys
+func resourceIDs(keys []string, resources map[string]confluent.Resource) []string {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, resources[key].ID)
+	}
+	sort.Strings(ids)
+	return slices.Compact(ids)
+}
+
+// sharedLabels returns the labels with the same value on every resource of the chunk
+func sharedLabels(chunk []string, resources map[string]confluent.Resource, labelBuilder *labels.Builder) map[string]string {
+	var shared map[string]string
+
+	for _, key := range chunk {
+		resourceLabels := labelBuilder.Build(resources[key])
+		if shared == nil {
+			shared = resourceLabels
+			continue
+		}
+		for k, v := range shared {
+			if resourceLabels[k] != v {
+				delete(shared, k)
+			}
+		}
+	}
+
+	return shared
+}
@@ -0,0 +1,149 @@
+package targets
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestChunk(t *testing.T) {
+	chunks := Chunk([]string{"lkc-c", "lkc-a", "lkc-b", "lkc-a", "lkc-d", "lkc-e"}, 2)
+
+	expected := [][]string{{"lkc-a", "lkc-b"}, {"lkc-c", "lkc-d"}, {"lkc-e"}}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("Expected %v, got %v", expected, chunks)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	var ids []string
+	for n := 0; n < 17; n++ {
+		ids = append(ids, fmt.Sprintf("lcc-%02d", n))
+	}
+
+	assigned := func(buckets []Bucket) map[string]string {
+		names := make(map[string]string)
+		for _, bucket := range buckets {
+			if len(bucket.IDs) > 10 {
+				t.Errorf("Expected at most 10 IDs in bucket %s, got %d", bucket.Name, len(bucket.IDs))
+			}
+			for _, id := range bucket.IDs {
+				if _, ok := names[id]; ok {
+					t.Errorf("Expected %s in a single bucket", id)
+				}
+				names[id] = bucket.Name
+			}
+		}
+		return names
+	}
+
+	before := assigned(Buckets(append(ids, ids[0]), 10))
+	if len(before) != len(ids) {
+		t.Fatalf("Expected %d assigned IDs, got %d", len(ids), len(before))
+	}
+
+	// Adding an ID leaves the other assignments unchanged
+	after := assigned(Buckets(append([]string{"lcc-new"}, ids...), 10))
+	for _, id := range ids {
+		if after[id] != before[id] {
+			t.Errorf("Expected %s to stay in bucket %s, got %s", id, before[id], after[id])
+		}
+	}
+
+	// So does removing one
+	removed := assigned(Buckets(ids[1:], 10))
+	for _, id := range ids[1:] {
+		if removed[id] != before[id] {
+			t.Errorf("Expected %s to stay in bucket %s, got %s", id, before[id], removed[id])
+		}
+	}
+
+	if buckets := Buckets(nil, 10); buckets != nil {
+		t.Errorf("Expected no buckets, got %v", buckets)
+	}
+}
+
+func TestFormatBatched(t *testing.T) {
+	resources := []confluent.Resource{
+		{ID: "lkc-b", ResourceType: "kafka", Labels: map[string]string{"environment_name": "prod", "cluster_name": "b"}},
+		{ID: "lkc-a", ResourceType: "kafka", Labels: map[string]string{"environment_name": "prod", "cluster_name": "a"}},
+		{ID: "lkc-c", ResourceType: "kafka", Labels: map[string]string{"environment_name": "prod", "cluster_name": "c"}},
+		{ID: "lsrc-a", ResourceType: "schema_registry", Labels: map[string]string{"environment_name": "prod"}},
+	}
+
+	assignment := Assignment{Fallback: []string{"api.telemetry.confluent.cloud"}}
+	response := FormatBatched(resources, assignment, "", nil, BatchLimits{"kafka": 10})
+
+	if len(response) != 2 {
+		t.Fatalf("Expected 2 target groups, got %d", len(response))
+	}
+
+	if !reflect.DeepEqual(response[0].Params["resource.kafka.id"], []string{"lkc-a", "lkc-b", "lkc-c"}) {
+		t.Errorf("Expected a single kafka bucket, got %v", response[0].Params)
+	}
+
+	expectedLabels := map[string]string{"environment_name": "prod", "resource_type": "kafka", "batch": "kafka-0"}
+	if !reflect.DeepEqual(response[0].Labels, expectedLabels) {
+		t.Errorf("Expected labels %v, got %v", expectedLabels, response[0].Labels)
+	}
+
+	if !reflect.DeepEqual(response[1].Params["resource.schema_registry.id"], []string{"lsrc-a"}) {
+		t.Errorf("Expected schema registry bucket, got %v", response[1].Params)
+	}
+
+	// Every resource lands in exactly one bucket within the limit
+	response = FormatBatched(resources, assignment, "", nil, BatchLimits{"kafka": 1})
+	seen := make(map[string]bool)
+	for _, target := range response {
+		ids := target.Params["resource.kafka.id"]
+		if len(ids) > 1 {
+			t.Errorf("Expected at most 1 ID per bucket, got %v", ids)
+		}
+		for _, id := range ids {
+			seen[id] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected every kafka cluster in a bucket, got %v", seen)
+	}
+}
+
+func TestFormatBatchedSameNamedConnectors(t *testing.T) {
+	resources := []confluent.Resource{
+		{ID: "orders-sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-a", "environment_name": "prod"}},
+		{ID: "orders-sink", ResourceType: "connector", Labels: map[string]string{"cluster_id": "lkc-b", "environment_name": "prod"}},
+	}
+
+	assignment := Assignment{Fallback: []string{"api.telemetry.confluent.cloud"}}
+	response := FormatBatched(resources, assignment, "", nil, BatchLimits{"connector": 1})
+
+	// Both connectors get a bucket, each passing the shared connector ID
+	if len(response) != 2 {
+		t.Fatalf("Expected 2 target groups, got %d", len(response))
+	}
+
+	clusters := make(map[string]bool)
+	for _, target := range response {
+		if !reflect.DeepEqual(target.Params["resource.connector.id"], []string{"orders-sink"}) {
+			t.Errorf("Expected connector ID orders-sink, got %v", target.Params)
+		}
+		clusters[target.Labels["cluster_id"]] = true
+	}
+	if !clusters["lkc-a"] || !clusters["lkc-b"] {
+		t.Errorf("Expected a target group per cluster, got %v", clusters)
+	}
+
+	// In a single bucket the connector ID is passed once and the cluster isn't shared
+	response = FormatBatched(resources, assignment, "", nil, BatchLimits{"connector": 10})
+	if len(response) != 1 {
+		t.Fatalf("Expected 1 target group, got %d", len(response))
+	}
+	if !reflect.DeepEqual(response[0].Params["resource.connector.id"], []string{"orders-sink"}) {
+		t.Errorf("Expected connector ID orders-sink once, got %v", response[0].Params)
+	}
+	if _, ok := response[0].Labels["cluster_id"]; ok {
+		t.Errorf("Expected no shared cluster_id label, got %v", response[0].Labels)
+	}
+}
@@ -0,0 +1,108 @@
+package targets
+
+import (
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+)
+
+const (
+	// DefaultProbeModule is used for resource types without a configured module
+	DefaultProbeModule = "tcp_connect"
+)
+
+// FormatProbes creates a target for every endpoint of every resource
+func FormatProbes(resources []confluent.Resource, prefix, module string, probeModules map[string]string, labelBuilder *labels.Builder) []Target {
+	var response []Target
+
+	for _, resource := range resources {
+		if len(resource.Endpoints) == 0 {
+			continue
+		}
+
+		resourceModule := module
+		if resourceModule == "" {
+			resourceModule = probeModules[resource.ResourceType]
+		}
+		if resourceModule == "" {
+			resourceModule = DefaultProbeModule
+		}
+
+		resourceLabels := labelBuilder.Build(resource)
+
+		// Iterate endpoints in a stable order
+		kinds := make([]string, 0, len(resource.Endpoints))
+		for kind := range resource.Endpoints {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			address, ok := probeAddress(resource.Endpoints[kind])
+			if !ok {
+				log.Printf("Warning: skipping unparseable %s endpoint %q of resource %s", kind, resource.Endpoints[kind], resource.ID)
+				continue
+			}
+
+			target := Target{
+				Targets: []string{address},
+				Labels:  make(map[string]string),
+				Params: map[string][]string{
+					"module": {resourceModule},
+				},
+			}
+
+			for k, v := range resourceLabels {
+				target.Labels[prefix+k] = v
+			}
+			target.Labels[prefix+"resource_id"] = resource.ID
+			target.Labels[prefix+"resource_type"] = resource.ResourceType
+			target.Labels[prefix+"endpoint"] = kind
+			target.Labels["probe_module"] = resourceModule
+
+			response = append(response, target)
+		}
+	}
+
+	return response
+}
+
+// probeAddress converts an endpoint such as "SASL_SSL://pkc-123.aws.confluent.cloud:9092"
+// or "https://psrc-123.aws.confluent.cloud" into a host:port address
+func probeAddress(endpoint string) (string, bool) {
+	scheme := ""
+	hostPort := endpoint
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		scheme = strings.ToLower(endpoint[:i])
+		hostPort = endpoint[i+3:]
+	}
+
+	// Drop any path from URL-style endpoints
+	if i := strings.IndexByte(hostPort, '/'); i >= 0 {
+		hostPort = hostPort[:i]
+	}
+
+	if host, port, err := net.SplitHostPort(hostPort); err == nil {
+		if host == "" || port == "" {
+			return "", false
+		}
+		return net.JoinHostPort(host, port), true
+	}
+
+	if hostPort == "" {
+		return "", false
+	}
+
+	switch scheme {
+	case "https":
+		return net.JoinHostPort(hostPort, "443"), true
+	case "http":
+		return net.JoinHostPort(hostPort, "80"), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,81 @@
+package targets
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestProbeAddress(t *testing.T) {
+	tests := map[string]string{
+		"SASL_SSL://pkc-123.us-west-2.aws.confluent.cloud:9092": "pkc-123.us-west-2.aws.confluent.cloud:9092",
+		"https://psrc-123.us-east-2.aws.confluent.cloud":        "psrc-123.us-east-2.aws.confluent.cloud:443",
+		"https://pksqlc-123.eu-west-1.aws.confluent.cloud:443":  "pksqlc-123.eu-west-1.aws.confluent.cloud:443",
+		"pkc-456.gcp.confluent.cloud:9092":                      "pkc-456.gcp.confluent.cloud:9092",
+	}
+
+	for endpoint, expected := range tests {
+		address, ok := probeAddress(endpoint)
+		if !ok || address != expected {
+			t.Errorf("probeAddress(%q): expected '%s', got '%s' (ok=%v)", endpoint, expected, address, ok)
+		}
+	}
+
+	// Endpoints without a port and an unknown scheme can't be probed
+	if _, ok := probeAddress("SASL_SSL://pkc-123.aws.confluent.cloud"); ok {
+		t.Error("Expected endpoint without port to be rejected")
+	}
+}
+
+func TestFormatProbes(t *testing.T) {
+	resources := []confluent.Resource{
+		{
+			ID:           "lkc-abc123",
+			ResourceType: "kafka",
+			Labels:       map[string]string{"cluster_name": "main"},
+			Endpoints:    map[string]string{"bootstrap": "SASL_SSL://pkc-123.aws.confluent.cloud:9092"},
+		},
+		{
+			ID:           "lsrc-abc123",
+			ResourceType: "schema_registry",
+			Labels:       map[string]string{"name": "sr"},
+			Endpoints:    map[string]string{"http": "https://psrc-123.aws.confluent.cloud"},
+		},
+		{
+			ID:           "lfcp-abc123",
+			ResourceType: "compute_pool",
+			Labels:       map[string]string{"name": "pool"},
+		},
+	}
+
+	response := FormatProbes(resources, "confluent_", "", map[string]string{"schema_registry": "tls_connect"}, nil)
+
+	if len(response) != 2 {
+		t.Fatalf("Expected 2 probe targets, got %d", len(response))
+	}
+
+	kafka := response[0]
+	if !reflect.DeepEqual(kafka.Targets, []string{"pkc-123.aws.confluent.cloud:9092"}) {
+		t.Errorf("Expected bootstrap address target, got %v", kafka.Targets)
+	}
+	if kafka.Labels["probe_module"] != DefaultProbeModule {
+		t.Errorf("Expected default probe module, got '%s'", kafka.Labels["probe_module"])
+	}
+	if kafka.Labels["confluent_cluster_name"] != "main" || kafka.Labels["confluent_resource_id"] != "lkc-abc123" {
+		t.Errorf("Expected resource metadata labels, got %v", kafka.Labels)
+	}
+
+	sr := response[1]
+	if sr.Labels["probe_module"] != "tls_connect" || !reflect.DeepEqual(sr.Params["module"], []string{"tls_connect"}) {
+		t.Errorf("Expected configured probe module, got labels %v, params %v", sr.Labels, sr.Params)
+	}
+
+	// The module query parameter overrides configured modules
+	response = FormatProbes(resources, "", "tcp_tls", map[string]string{"schema_registry": "tls_connect"}, nil)
+	for _, target := range response {
+		if target.Labels["probe_module"] != "tcp_tls" {
+			t.Errorf("Expected module override 'tcp_tls', got '%s'", target.Labels["probe_module"])
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package targets
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+)
+
+const (
+	// MetaLabelPrefix is the prefix of labels following the Prometheus service discovery conventions
+	MetaLabelPrefix = "__meta_confluent_"
+)
+
+var (
+	// validPrefixPattern is used to validate label prefixes
+	validPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_]*$`)
+
+	// ResourceTypes lists the resource types produced by the Confluent client, in sorted order
+	ResourceTypes = sortedResourceTypes()
+)
+
+// sortedResourceTypes returns a sorted copy of the Confluent client's resource types
+func sortedResourceTypes() []string {
+	resourceTypes := slices.Clone(confluent.ResourceTypes)
+	slices.Sort(resourceTypes)
+	return resourceTypes
+}
+
+// Target represents a target for Prometheus to scrape
+type Target struct {
+	Targets []string            `json:"targets" yaml:"targets"`
+	Labels  map[string]string   `json:"labels" yaml:"labels"`
+	Params  map[string][]string `json:"params" yaml:"params,omitempty"`
+}
+
+// ResourceKey identifies a resource among all types, e.g. "kafka-lkc-abc123".
+// Connector names are only unique within their Kafka cluster, so connector
+// keys include the cluster ID.
+func ResourceKey(resource confluent.Resource) string {
+	if clusterID := resource.Labels["cluster_id"]; resource.ResourceType == "connector" && clusterID != "" {
+		return resource.ResourceType + "-" + clusterID + "-" + resource.ID
+	}
+	return resource.ResourceType + "-" + resource.ID
+}
+
+// Assignment decides which scrape targets each resource type gets
+type Assignment struct {
+	Fallback []string
+	ByType   map[string][]string
+}
+
+// FromProfile creates an assignment from a configured target profile
+func FromProfile(profile config.TargetProfile) Assignment {
+	assignment := Assignment{
+		Fallback: profile.Targets,
+		ByType:   make(map[string][]string),
+	}
+
+	for resourceType, targets := range profile.ResourceTypes {
+		assignment.ByType[resourceType] = targets
+	}
+
+	return assignment
+}
+
+// ForType returns the targets for a resource type, falling back to the global targets
+func (a Assignment) ForType(resourceType string) []string {
+	if targets, ok := a.ByType[resourceType]; ok {
+		return targets
+	}
+	return a.Fallback
+}
+
+// Empty reports whether the assignment has no targets at all
+func (a Assignment) Empty() bool {
+	return len(a.Fallback) == 0 && len(a.ByType) == 0
+}
+
+// IsResourceType reports whether the name is a known resource type
+func IsResourceType(name string) bool {
+	for _, resourceType := range ResourceTypes {
+		if resourceType == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefix validates a label prefix and adds a trailing underscore if it's not empty
+func ParsePrefix(prefix string) (string, error) {
+	if prefix != "" && !validPrefixPattern.MatchString(prefix) {
+		return "", errors.New("Invalid 'prefix' parameter. Must contain only alphanumeric characters and underscores")
+	}
+
+	// Add trailing underscore to prefix if it's not empty
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix = prefix + "_"
+	}
+
+	return prefix, nil
+}
+
+// Format builds the Prometheus target groups for the resources
+func Format(resources []confluent.Resource, assignment Assignment, prefix string, labelBuilder *labels.Builder) []Target {
+	var response []Target
+
+	for _, resource := range resources {
+		// Skip resource types without any targets to scrape
+		resourceTargets := assignment.ForType(resource.ResourceType)
+		if len(resourceTargets) == 0 {
+			continue
+		}
+
+		// Create a new target with the requested targets
+		target := Target{
+			Targets: resourceTargets,
+			Labels:  make(map[string]string),
+			Params:  make(map[string][]string),
+		}
+
+		// Add normalised resource and template labels with optional prefix
+		for k, v := range labelBuilder.Build(resource) {
+			target.Labels[prefix+k] = v
+		}
+
+		// Add resource ID to params based on resource type
+		switch resource.ResourceType {
+		case "kafka":
+			target.Params["resource.kafka.id"] = []string{resource.ID}
+		case "schema_registry":
+			target.Params["resource.schema_registry.id"] = []string{resource.ID}
+		case "ksql":
+			target.Params["resource.ksql.id"] = []string{resource.ID}
+		case "compute_pool":
+			target.Params["resource.compute_pool.id"] = []string{resource.ID}
+		case "connector":
+			target.Params["resource.connector.id"] = []string{resource.ID}
+		}
+
+		response = append(response, target)
+	}
+
+	return response
+}
+
+// FormatMeta builds target groups following the Prometheus service discovery
+// conventions: every label is exposed as __meta_confluent_<name>, including
+// the resource ID and type, so it can be mapped or dropped with relabeling.
+func FormatMeta(resources []confluent.Resource, assignment Assignment, labelBuilder *labels.Builder) []Target {
+	var response []Target
+
+	for _, resource := range resources {
+		groups := Format([]confluent.Resource{resource}, assignment, MetaLabelPrefix, labelBuilder)
+		if len(groups) == 0 {
+			continue
+		}
+
+		target := groups[0]
+		target.Labels[MetaLabelPrefix+"resource_id"] = resource.ID
+		target.Labels[MetaLabelPrefix+"resource_type"] = resource.ResourceType
+		response = append(response, target)
+	}
+
+	return response
+}
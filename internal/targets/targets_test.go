@@ -0,0 +1,75 @@
+package targets
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestFromProfile(t *testing.T) {
+	assignment := FromProfile(config.TargetProfile{
+		Targets:       []string{"api.telemetry.confluent.cloud"},
+		ResourceTypes: map[string][]string{"connector": {"connect-health:9100"}},
+	})
+
+	if !reflect.DeepEqual(assignment.ForType("kafka"), []string{"api.telemetry.confluent.cloud"}) {
+		t.Errorf("Expected fallback targets for kafka, got %v", assignment.ForType("kafka"))
+	}
+
+	if !reflect.DeepEqual(assignment.ForType("connector"), []string{"connect-health:9100"}) {
+		t.Errorf("Expected connector targets, got %v", assignment.ForType("connector"))
+	}
+}
+
+func TestFormatTargetsByType(t *testing.T) {
+	resources := []confluent.Resource{
+		{ID: "lkc-abc123", ResourceType: "kafka", Labels: map[string]string{"cluster_name": "main"}},
+		{ID: "lcc-abc123", ResourceType: "connector", Labels: map[string]string{"connector_name": "sink"}},
+	}
+
+	assignment := Assignment{
+		ByType: map[string][]string{"connector": {"connect-health:9100"}},
+	}
+
+	response := Format(resources, assignment, "confluent_", nil)
+
+	// Kafka has no targets without a global fallback and is skipped
+	if len(response) != 1 {
+		t.Fatalf("Expected 1 target group, got %d", len(response))
+	}
+
+	if !reflect.DeepEqual(response[0].Targets, []string{"connect-health:9100"}) {
+		t.Errorf("Expected connector targets, got %v", response[0].Targets)
+	}
+
+	if response[0].Labels["confluent_connector_name"] != "sink" {
+		t.Errorf("Expected prefixed connector_name label, got %v", response[0].Labels)
+	}
+
+	if !reflect.DeepEqual(response[0].Params["resource.connector.id"], []string{"lcc-abc123"}) {
+		t.Errorf("Expected connector ID param, got %v", response[0].Params)
+	}
+}
+
+func TestFormatMeta(t *testing.T) {
+	resources := []confluent.Resource{
+		{ID: "lkc-abc123", ResourceType: "kafka", Labels: map[string]string{"cluster_name": "main"}},
+	}
+
+	response := FormatMeta(resources, Assignment{Fallback: []string{"api.telemetry.confluent.cloud"}}, nil)
+
+	if len(response) != 1 {
+		t.Fatalf("Expected 1 target group, got %d", len(response))
+	}
+
+	expected := map[string]string{
+		"__meta_confluent_cluster_name":  "main",
+		"__meta_confluent_resource_id":   "lkc-abc123",
+		"__meta_confluent_resource_type": "kafka",
+	}
+	if !reflect.DeepEqual(response[0].Labels, expected) {
+		t.Errorf("Expected labels %v, got %v", expected, response[0].Labels)
+	}
+}
@@ -0,0 +1,356 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+const (
+	// FormatJSON posts the added and removed resources as JSON
+	FormatJSON = "json"
+	// FormatSlack posts a message for Slack incoming webhooks and compatible receivers
+	FormatSlack = "slack"
+
+	// SignatureHeader carries the HMAC-SHA256 signature of signed payloads
+	SignatureHeader = "X-Signature-256"
+
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = time.Second
+	maxBackoff        = time.Minute
+
+	// queueSize bounds the diffs waiting for delivery per webhook
+	queueSize = 16
+	// slackMaxLines bounds the resources listed in a Slack message
+	slackMaxLines = 50
+)
+
+// Payload is the body of JSON notifications
+type Payload struct {
+	DetectedAt time.Time          `json:"detected_at"`
+	Added      []inventory.Change `json:"added"`
+	Removed    []inventory.Change `json:"removed"`
+}
+
+// slackPayload is the body of Slack notifications
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// StatusError is returned when a receiver responds with an error status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("receiver returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Notifier delivers inventory changes to the configured webhooks. Every
+// webhook has its own queue and worker, so a slow receiver doesn't delay
+// the others or the refresh that detected the changes.
+type Notifier struct {
+	hooks  []*hook
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// hook is a single configured webhook
+type hook struct {
+	name          string
+	url           string
+	format        string
+	secret        string
+	resourceTypes map[string]bool
+	httpClient    *http.Client
+	maxRetries    int
+	backoff       time.Duration
+	queue         chan inventory.Diff
+}
+
+// NewNotifier creates a notifier for the webhooks and starts their workers
+func NewNotifier(cfgs []config.WebhookConfig) (*Notifier, error) {
+	var hooks []*hook
+	for index, cfg := range cfgs {
+		h, err := newHook(index, cfg)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{hooks: hooks, cancel: cancel}
+	for _, h := range hooks {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			h.run(ctx)
+		}()
+	}
+
+	return n, nil
+}
+
+// newHook validates the webhook configuration and fills in defaults
+func newHook(index int, cfg config.WebhookConfig) (*hook, error) {
+	h := &hook{
+		name:       cfg.Name,
+		url:        os.ExpandEnv(cfg.URL),
+		format:     cfg.Format,
+		secret:     os.ExpandEnv(cfg.Secret),
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		queue:      make(chan inventory.Diff, queueSize),
+	}
+	if h.name == "" {
+		h.name = fmt.Sprintf("webhook-%d", index)
+	}
+
+	if h.url == "" {
+		return nil, fmt.Errorf("webhook %s: url is required", h.name)
+	}
+	if parsed, err := url.Parse(h.url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("webhook %s: invalid url, must be an http or https URL", h.name)
+	}
+
+	switch h.format {
+	case "":
+		h.format = FormatJSON
+	case FormatJSON, FormatSlack:
+	default:
+		return nil, fmt.Errorf("webhook %s: unknown format %q, must be %q or %q", h.name, h.format, FormatJSON, FormatSlack)
+	}
+
+	if len(cfg.ResourceTypes) > 0 {
+		h.resourceTypes = make(map[string]bool, len(cfg.ResourceTypes))
+		for _, resourceType := range cfg.ResourceTypes {
+			if !targets.IsResourceType(resourceType) {
+				return nil, fmt.Errorf("webhook %s: unknown resource type %q", h.name, resourceType)
+			}
+			h.resourceTypes[resourceType] = true
+		}
+	}
+
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		parsed, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("webhook %s: invalid timeout %q", h.name, cfg.Timeout)
+		}
+		timeout = parsed
+	}
+	h.httpClient = &http.Client{Timeout: timeout}
+
+	if cfg.MaxRetries != nil {
+		if *cfg.MaxRetries < 0 {
+			return nil, fmt.Errorf("webhook %s: max_retries must not be negative, got %d", h.name, *cfg.MaxRetries)
+		}
+		h.maxRetries = *cfg.MaxRetries
+	}
+
+	if cfg.Backoff != "" {
+		parsed, err := time.ParseDuration(cfg.Backoff)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("webhook %s: invalid backoff %q", h.name, cfg.Backoff)
+		}
+		h.backoff = parsed
+	}
+
+	return h, nil
+}
+
+// Notify queues the diff for every webhook without blocking. Diffs are
+// dropped with a warning when a webhook's queue is full.
+func (n *Notifier) Notify(diff inventory.Diff) {
+	for _, h := range n.hooks {
+		select {
+		case h.queue <- diff:
+		default:
+			log.Printf("Warning: webhook %s queue is full, dropping changes detected at %v", h.name, diff.DetectedAt)
+		}
+	}
+}
+
+// Stop cancels pending deliveries and waits for the workers to exit. It is
+// safe to call more than once.
+func (n *Notifier) Stop() {
+	n.once.Do(func() {
+		n.cancel()
+		n.wg.Wait()
+	})
+}
+
+// run delivers queued diffs until the context is done
+func (h *hook) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case diff := <-h.queue:
+			if err := h.deliver(ctx, diff); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+}
+
+// deliver posts the diff, retrying failed attempts with exponential backoff
+func (h *hook) deliver(ctx context.Context, diff inventory.Diff) error {
+	body, err := h.payload(diff)
+	if err != nil {
+		return fmt.Errorf("webhook %s: failed to encode payload: %w", h.name, err)
+	}
+	if body == nil {
+		return nil
+	}
+
+	backoff := h.backoff
+	for attempt := 1; ; attempt++ {
+		err := h.send(ctx, body)
+		if err == nil {
+			log.Printf("Webhook %s notified of changes detected at %v", h.name, diff.DetectedAt)
+			return nil
+		}
+		if attempt > h.maxRetries || !retryable(err) {
+			return fmt.Errorf("webhook %s failed after %d attempts: %w", h.name, attempt, err)
+		}
+
+		log.Printf("Warning: webhook %s attempt %d failed, retrying in %v: %v", h.name, attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("webhook %s cancelled: %w", h.name, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// send makes a single delivery attempt
+func (h *hook) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.secret, body))
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+	return nil
+}
+
+// retryable reports whether a failed attempt may succeed when repeated.
+// Client errors other than rate limiting are permanent.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// payload encodes the added and removed resources of the webhook's resource
+// types, or returns nil if there are none
+func (h *hook) payload(diff inventory.Diff) ([]byte, error) {
+	payload := Payload{
+		DetectedAt: diff.DetectedAt,
+		Added:      h.filter(diff.Added),
+		Removed:    h.filter(diff.Removed),
+	}
+	if len(payload.Added) == 0 && len(payload.Removed) == 0 {
+		return nil, nil
+	}
+
+	if h.format == FormatSlack {
+		return json.Marshal(slackPayload{Text: slackText(payload)})
+	}
+	return json.Marshal(payload)
+}
+
+// filter keeps the changes of the webhook's resource types
+func (h *hook) filter(changes []inventory.Change) []inventory.Change {
+	filtered := []inventory.Change{}
+	for _, change := range changes {
+		if h.resourceTypes == nil || h.resourceTypes[change.ResourceType] {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// slackText formats the changes as a Slack message
+func slackText(payload Payload) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*Confluent Cloud resources changed*: %d added, %d removed", len(payload.Added), len(payload.Removed))
+
+	lines := 0
+	for _, group := range []struct {
+		verb    string
+		changes []inventory.Change
+	}{{"Added", payload.Added}, {"Removed", payload.Removed}} {
+		for _, change := range group.changes {
+			if lines == slackMaxLines {
+				fmt.Fprintf(&text, "\n…and %d more", len(payload.Added)+len(payload.Removed)-lines)
+				return text.String()
+			}
+			fmt.Fprintf(&text, "\n• %s %s `%s`", group.verb, change.ResourceType, change.ID)
+			if name := displayName(change); name != "" {
+				fmt.Fprintf(&text, " (%s)", name)
+			}
+			if environment := change.Labels["environment_name"]; environment != "" {
+				fmt.Fprintf(&text, " in %s", environment)
+			}
+			lines++
+		}
+	}
+	return text.String()
+}
+
+// displayName returns the resource's name label, which differs between resource types
+func displayName(change inventory.Change) string {
+	for _, label := range []string{"cluster_name", "connector_name", "name"} {
+		if name := change.Labels[label]; name != "" && name != change.ID {
+			return name
+		}
+	}
+	return ""
+}
+
+// Sign returns the signature header value of a payload: "sha256=" followed
+// by the hex encoded HMAC-SHA256 of the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/config"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/inventory"
+)
+
+// receiver records the requests to an httptest server, failing the first ones
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	bodies   [][]byte
+	headers  []http.Header
+	attempts int
+	received chan struct{}
+}
+
+func newReceiver(t *testing.T, failures, status int) (*receiver, *httptest.Server) {
+	r := &receiver{failures: failures, status: status, received: make(chan struct{}, 10)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.attempts++
+		if r.attempts <= r.failures {
+			http.Error(w, "unavailable", r.status)
+			return
+		}
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, req.Header.Clone())
+		r.received <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *receiver) attemptCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.attempts
+}
+
+func testDiff() inventory.Diff {
+	return inventory.Diff{
+		DetectedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Added: []inventory.Change{
+			{ResourceType: "connector", ID: "lcc-a", Labels: map[string]string{"connector_name": "orders-sink", "environment_name": "prod"}},
+			{ResourceType: "kafka", ID: "lkc-a", Labels: map[string]string{"cluster_name": "orders", "environment_name": "prod"}},
+		},
+		Removed: []inventory.Change{
+			{ResourceType: "ksql", ID: "lksqlc-a"},
+		},
+		LabelsChanged: []inventory.Change{
+			{ResourceType: "kafka", ID: "lkc-b"},
+		},
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestNotifierDeliversSignedJSON(t *testing.T) {
+	rec, server := newReceiver(t, 2, http.StatusServiceUnavailable)
+
+	notifier, err := NewNotifier([]config.WebhookConfig{{
+		Name:          "platform",
+		URL:           server.URL,
+		Secret:        "s3cret",
+		ResourceTypes: []string{"kafka", "connector"},
+		Backoff:       "1ms",
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	defer notifier.Stop()
+
+	notifier.Notify(testDiff())
+
+	select {
+	case <-rec.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the webhook to be delivered")
+	}
+
+	if attempts := rec.attemptCount(); attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	rec.mu.Lock()
+	body, header := rec.bodies[0], rec.headers[0]
+	rec.mu.Unlock()
+
+	if signature := header.Get(SignatureHeader); signature != Sign("s3cret", body) {
+		t.Errorf("Expected a valid signature, got %q", signature)
+	}
+	if contentType := header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", contentType)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if len(payload.Added) != 2 || payload.Added[1].ID != "lkc-a" {
+		t.Errorf("Expected the added connector and Kafka cluster, got %+v", payload.Added)
+	}
+	// The ksql removal is filtered out
+	if len(payload.Removed) != 0 {
+		t.Errorf("Expected no removed resources, got %+v", payload.Removed)
+	}
+}
+
+func TestDeliverSlack(t *testing.T) {
+	rec, server := newReceiver(t, 0, 0)
+
+	h, err := newHook(0, config.WebhookConfig{URL: server.URL, Format: FormatSlack})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if err := h.deliver(t.Context(), testDiff()); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+
+	var payload slackPayload
+	if err := json.Unmarshal(rec.bodies[0], &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	expected := "*Confluent Cloud resources changed*: 2 added, 1 removed\n" +
+		"• Added connector `lcc-a` (orders-sink) in prod\n" +
+		"• Added kafka `lkc-a` (orders) in prod\n" +
+		"• Removed ksql `lksqlc-a`"
+	if payload.Text != expected {
+		t.Errorf("Expected Slack text:\n%s\ngot:\n%s", expected, payload.Text)
+	}
+	if rec.headers[0].Get(SignatureHeader) != "" {
+		t.Errorf("Expected unsigned payload without a secret")
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	// Client errors are not retried
+	rec, server := newReceiver(t, 1, http.StatusBadRequest)
+	h, err := newHook(0, config.WebhookConfig{URL: server.URL, Backoff: "1ms"})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if err := h.deliver(t.Context(), testDiff()); err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("Expected the client error, got %v", err)
+	}
+	if attempts := rec.attemptCount(); attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+
+	// Retries stop after max_retries
+	rec, server = newReceiver(t, 5, http.StatusTooManyRequests)
+	h, err = newHook(0, config.WebhookConfig{URL: server.URL, Backoff: "1ms", MaxRetries: intPtr(2)})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if err := h.deliver(t.Context(), testDiff()); err == nil {
+		t.Errorf("Expected an error after exhausting the retries, got nil")
+	}
+	if attempts := rec.attemptCount(); attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	// Diffs without matching changes are not delivered
+	h, err = newHook(0, config.WebhookConfig{URL: server.URL, ResourceTypes: []string{"compute_pool"}})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if err := h.deliver(t.Context(), testDiff()); err != nil || rec.attemptCount() != 3 {
+		t.Errorf("Expected no delivery, got %d attempts, err %v", rec.attemptCount(), err)
+	}
+}
+
+func TestNewNotifierErrors(t *testing.T) {
+	invalid := []config.WebhookConfig{
+		{},
+		{URL: "ftp://example.com"},
+		{URL: "https://example.com", Format: "xml"},
+		{URL: "https://example.com", ResourceTypes: []string{"topic"}},
+		{URL: "https://example.com", Timeout: "soon"},
+		{URL: "https://example.com", MaxRetries: intPtr(-1)},
+		{URL: "https://example.com", Backoff: "0s"},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewNotifier([]config.WebhookConfig{cfg}); err == nil {
+			t.Errorf("Expected an error for %+v, got nil", cfg)
+		}
+	}
+
+	t.Setenv("TEST_WEBHOOK_URL", "https://hooks.example.com/T000")
+	h, err := newHook(0, config.WebhookConfig{URL: "${TEST_WEBHOOK_URL}"})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if h.url != "https://hooks.example.com/T000" || h.name != "webhook-0" {
+		t.Errorf("Expected the expanded URL and default name, got %q and %q", h.url, h.name)
+	}
+}
@@ -0,0 +1,164 @@
+// Package confluentsd implements a native Prometheus service discovery
+// mechanism for Confluent Cloud resources.
+//
+// Importing the package registers the "confluent_sd_configs" section with
+// Prometheus' configuration, so it can be compiled into a custom Prometheus
+// or agent build:
+//
+//	import _ "github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/pkg/confluentsd"
+package confluentsd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/refresh"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/labels"
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/targets"
+)
+
+// DefaultSDConfig is the default Confluent Cloud SD configuration.
+var DefaultSDConfig = SDConfig{
+	RefreshInterval: model.Duration(30 * time.Minute),
+	Targets:         []string{"api.telemetry.confluent.cloud"},
+}
+
+func init() {
+	discovery.RegisterConfig(&SDConfig{})
+}
+
+// SDConfig is the configuration for Confluent Cloud based service discovery.
+type SDConfig struct {
+	APIKey          string         `yaml:"api_key"`
+	APISecret       config.Secret  `yaml:"api_secret"`
+	RefreshInterval model.Duration `yaml:"refresh_interval,omitempty"`
+	// Targets are the scrape addresses given to every resource.
+	Targets []string `yaml:"targets,omitempty"`
+}
+
+// NewDiscovererMetrics implements discovery.Config.
+func (*SDConfig) NewDiscovererMetrics(_ prometheus.Registerer, rmi discovery.RefreshMetricsInstantiator) discovery.DiscovererMetrics {
+	return &confluentMetrics{
+		refreshMetrics: rmi,
+	}
+}
+
+// Name returns the name of the Config.
+func (*SDConfig) Name() string { return "confluent" }
+
+// NewDiscoverer returns a Discoverer for the Config.
+func (c *SDConfig) NewDiscoverer(opts discovery.DiscovererOptions) (discovery.Discoverer, error) {
+	return NewDiscovery(c, opts)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SDConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	*c = DefaultSDConfig
+	type plain SDConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if c.APIKey == "" || c.APISecret == "" {
+		return errors.New("confluent SD configuration requires api_key and api_secret")
+	}
+	if len(c.Targets) == 0 {
+		return errors.New("confluent SD configuration requires at least one target")
+	}
+	return nil
+}
+
+// Discovery periodically fetches Confluent Cloud resources. It implements
+// the Discoverer interface.
+type Discovery struct {
+	*refresh.Discovery
+	client  *confluent.Client
+	targets []string
+}
+
+// NewDiscovery returns a new Discovery which periodically refreshes its targets.
+func NewDiscovery(conf *SDConfig, opts discovery.DiscovererOptions) (*Discovery, error) {
+	m, ok := opts.Metrics.(*confluentMetrics)
+	if !ok {
+		return nil, errors.New("invalid discovery metrics type")
+	}
+
+	d := &Discovery{
+		client:  confluent.NewClient(conf.APIKey, string(conf.APISecret)),
+		targets: conf.Targets,
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	d.Discovery = refresh.NewDiscovery(
+		refresh.Options{
+			Logger:              logger,
+			Mech:                "confluent",
+			SetName:             opts.SetName,
+			Interval:            time.Duration(conf.RefreshInterval),
+			RefreshF:            d.refresh,
+			MetricsInstantiator: m.refreshMetrics,
+		},
+	)
+	return d, nil
+}
+
+func (d *Discovery) refresh(ctx context.Context) ([]*targetgroup.Group, error) {
+	resources, err := d.client.WithContext(ctx).GetAllResources()
+	if err != nil {
+		return nil, err
+	}
+	// Requests cancelled midway leave segments out instead of failing the fetch
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []*targetgroup.Group{buildGroup(resources, d.targets)}, nil
+}
+
+// buildGroup converts resources into a target group, using the same label
+// logic as the HTTP endpoint with every label exposed as __meta_confluent_<name>
+// and the resource ID passed as a scrape parameter.
+func buildGroup(resources []confluent.Resource, scrapeTargets []string) *targetgroup.Group {
+	tg := &targetgroup.Group{
+		Source: "Confluent",
+	}
+
+	// Confluent labels are only sanitised, matching /discovery without any configuration
+	builder := labels.NewBuilder(nil, nil)
+	assignment := targets.Assignment{Fallback: scrapeTargets}
+
+	for _, group := range targets.FormatMeta(resources, assignment, builder) {
+		for _, address := range group.Targets {
+			labelSet := model.LabelSet{
+				model.AddressLabel: model.LabelValue(address),
+			}
+
+			for name, value := range group.Labels {
+				labelSet[model.LabelName(name)] = model.LabelValue(value)
+			}
+
+			for name, values := range group.Params {
+				if len(values) > 0 {
+					labelSet[model.LabelName(model.ParamLabelPrefix+name)] = model.LabelValue(values[0])
+				}
+			}
+
+			tg.Targets = append(tg.Targets, labelSet)
+		}
+	}
+
+	return tg
+}
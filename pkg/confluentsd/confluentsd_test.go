@@ -0,0 +1,72 @@
+package confluentsd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"github.com/cjmatta/prometheus-http-servicediscovery-confluent-cloud/internal/confluent"
+)
+
+func TestUnmarshalSDConfig(t *testing.T) {
+	var cfg SDConfig
+	if err := yaml.Unmarshal([]byte("api_key: key\napi_secret: secret\n"), &cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.RefreshInterval != DefaultSDConfig.RefreshInterval {
+		t.Errorf("Expected default refresh interval %v, got %v", DefaultSDConfig.RefreshInterval, cfg.RefreshInterval)
+	}
+	if len(cfg.Targets) != 1 || cfg.Targets[0] != "api.telemetry.confluent.cloud" {
+		t.Errorf("Expected default targets, got %v", cfg.Targets)
+	}
+
+	if err := yaml.Unmarshal([]byte("api_key: key\n"), &cfg); err == nil {
+		t.Errorf("Expected error for missing api_secret, got nil")
+	}
+}
+
+func TestBuildGroup(t *testing.T) {
+	resources := []confluent.Resource{
+		{
+			ID:           "lkc-abc123",
+			ResourceType: "kafka",
+			Labels:       map[string]string{"cluster_name": "orders", "environment_id": "env-1"},
+		},
+	}
+
+	tg := buildGroup(resources, []string{"api.telemetry.confluent.cloud"})
+
+	if len(tg.Targets) != 1 {
+		t.Fatalf("Expected 1 target, got %d", len(tg.Targets))
+	}
+
+	expected := model.LabelSet{
+		"__address__":                     "api.telemetry.confluent.cloud",
+		"__meta_confluent_resource_id":    "lkc-abc123",
+		"__meta_confluent_resource_type":  "kafka",
+		"__meta_confluent_cluster_name":   "orders",
+		"__meta_confluent_environment_id": "env-1",
+		"__param_resource.kafka.id":       "lkc-abc123",
+	}
+
+	for name, value := range expected {
+		if tg.Targets[0][name] != value {
+			t.Errorf("Expected label %s=%q, got %q", name, value, tg.Targets[0][name])
+		}
+	}
+}
+
+func TestRefreshCancelled(t *testing.T) {
+	d := &Discovery{client: confluent.NewClient("key", "secret"), targets: []string{"api.telemetry.confluent.cloud"}}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := d.refresh(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the refresh to stop with the context, got %v", err)
+	}
+}